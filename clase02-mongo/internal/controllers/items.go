@@ -3,6 +3,7 @@ package controllers
 import (
 	"clase02-mongo/internal/domain"
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -69,25 +70,92 @@ func (c *ItemsController) GetItems(ctx *gin.Context) {
 // CreateItem maneja POST /items - Crea un nuevo item
 // Consigna 1: Recibir JSON, validar y crear item
 func (c *ItemsController) CreateItem(ctx *gin.Context) {
-	ctx.JSON(http.StatusNotImplemented, gin.H{"error": "TODO: implementar CreateItem"})
+	// 📥 Parsear el body JSON al modelo de dominio
+	var item domain.Item
+	if err := ctx.ShouldBindJSON(&item); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid JSON body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	created, err := c.service.Create(ctx.Request.Context(), item)
+	if err != nil {
+		respondError(ctx, "Failed to create item", err)
+		return
+	}
+
+	// ✅ 201 Created con el item creado (incluye ID y timestamps)
+	ctx.JSON(http.StatusCreated, created)
 }
 
 // GetItemByID maneja GET /items/:id - Obtiene item por ID
 // Consigna 2: Extraer ID del path param, validar y buscar
 func (c *ItemsController) GetItemByID(ctx *gin.Context) {
-	ctx.JSON(http.StatusNotImplemented, gin.H{"error": "TODO: implementar GetItemByID"})
+	id := ctx.Param("id")
+
+	item, err := c.service.GetByID(ctx.Request.Context(), id)
+	if err != nil {
+		respondError(ctx, "Failed to get item", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, item)
 }
 
 // UpdateItem maneja PUT /items/:id - Actualiza item existente
 // Consigna 3: Extraer ID y datos, validar y actualizar
 func (c *ItemsController) UpdateItem(ctx *gin.Context) {
-	ctx.JSON(http.StatusNotImplemented, gin.H{"error": "TODO: implementar UpdateItem"})
+	id := ctx.Param("id")
+
+	var item domain.Item
+	if err := ctx.ShouldBindJSON(&item); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid JSON body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	updated, err := c.service.Update(ctx.Request.Context(), id, item)
+	if err != nil {
+		respondError(ctx, "Failed to update item", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updated)
 }
 
 // DeleteItem maneja DELETE /items/:id - Elimina item por ID
 // Consigna 4: Extraer ID, validar y eliminar
 func (c *ItemsController) DeleteItem(ctx *gin.Context) {
-	ctx.JSON(http.StatusNotImplemented, gin.H{"error": "TODO: implementar DeleteItem"})
+	id := ctx.Param("id")
+
+	if err := c.service.Delete(ctx.Request.Context(), id); err != nil {
+		respondError(ctx, "Failed to delete item", err)
+		return
+	}
+
+	// ✅ 204 No Content: borrado exitoso, sin body
+	ctx.Status(http.StatusNoContent)
+}
+
+// respondError traduce los errores de dominio a status HTTP
+// 🎯 Helper para manejar respuestas de error de manera consistente
+func respondError(ctx *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, domain.ErrInvalidID), errors.Is(err, domain.ErrInvalidItem):
+		status = http.StatusBadRequest
+	case errors.Is(err, domain.ErrNotFound):
+		status = http.StatusNotFound
+	}
+
+	ctx.JSON(status, gin.H{
+		"error":   message,
+		"details": err.Error(),
+	})
 }
 
 // 📚 Notas sobre HTTP Status Codes
@@ -100,5 +168,4 @@ func (c *ItemsController) DeleteItem(ctx *gin.Context) {
 // 500 Internal Server Error - Error interno del servidor
 // 501 Not Implemented - Funcionalidad no implementada (para TODOs)
 //
-// 💡 Tip: En una API real, sería buena práctica crear una función
-// helper para manejar respuestas de error de manera consistente
+// 💡 Tip: respondError centraliza el mapeo error de dominio -> status HTTP
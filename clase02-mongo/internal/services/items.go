@@ -3,7 +3,6 @@ package services
 import (
 	"clase02-mongo/internal/domain"
 	"context"
-	"errors"
 	"fmt"
 	"strings"
 )
@@ -50,6 +49,10 @@ func (s *ItemsServiceImpl) List(ctx context.Context) ([]domain.Item, error) {
 // Create valida y crea un nuevo item
 // Consigna 1: Validar name no vacío y price >= 0
 func (s *ItemsServiceImpl) Create(ctx context.Context, item domain.Item) (domain.Item, error) {
+	if err := s.validateItem(item); err != nil {
+		return domain.Item{}, err
+	}
+
 	created, err := s.repository.Create(ctx, item)
 	if err != nil {
 		return domain.Item{}, fmt.Errorf("error creating item in repository: %w", err)
@@ -72,21 +75,26 @@ func (s *ItemsServiceImpl) GetByID(ctx context.Context, id string) (domain.Item,
 // Update actualiza un item existente
 // Consigna 3: Validar campos antes de actualizar
 func (s *ItemsServiceImpl) Update(ctx context.Context, id string, item domain.Item) (domain.Item, error) {
+	if err := s.validateItem(item); err != nil {
+		return domain.Item{}, err
+	}
 
-	// TODO: Actualizar en DB
-	// TODO: Guardar en cache
+	updated, err := s.repository.Update(ctx, id, item)
+	if err != nil {
+		return domain.Item{}, fmt.Errorf("error updating item in repository: %w", err)
+	}
 
-	return domain.Item{}, errors.New("TODO: implementar Update")
+	return updated, nil
 }
 
 // Delete elimina un item por ID
 // Consigna 4: Validar ID antes de eliminar
 func (s *ItemsServiceImpl) Delete(ctx context.Context, id string) error {
+	if err := s.repository.Delete(ctx, id); err != nil {
+		return fmt.Errorf("error deleting item from repository: %w", err)
+	}
 
-	// TODO: Borrar de cache
-	// TODO: Borrar de DB
-
-	return errors.New("TODO: implementar Delete")
+	return nil
 }
 
 // validateItem aplica reglas de negocio para validar un item
@@ -94,12 +102,12 @@ func (s *ItemsServiceImpl) Delete(ctx context.Context, id string) error {
 func (s *ItemsServiceImpl) validateItem(item domain.Item) error {
 	// 📝 Name es obligatorio y no puede estar vacío
 	if strings.TrimSpace(item.Name) == "" {
-		return errors.New("name is required and cannot be empty")
+		return fmt.Errorf("%w: name is required and cannot be empty", domain.ErrInvalidItem)
 	}
 
 	// 💰 Price debe ser >= 0 (productos gratis están permitidos)
 	if item.Price < 0 {
-		return errors.New("price must be greater than or equal to 0")
+		return fmt.Errorf("%w: price must be greater than or equal to 0", domain.ErrInvalidItem)
 	}
 
 	// ✅ Todas las validaciones pasaron
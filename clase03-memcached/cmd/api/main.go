@@ -61,7 +61,6 @@ func main() {
 	// GET /items - listar todos los items (✅ implementado)
 	router.GET("/items", itemController.GetItems)
 
-	// TODO: Implementar la lógica de estos endpoints (actualmente retornan 501)
 	// POST /items - crear nuevo item
 	router.POST("/items", itemController.CreateItem)
 
@@ -0,0 +1,20 @@
+package domain
+
+import "errors"
+
+// Errores de dominio compartidos entre capas
+// El repository los retorna, el service los propaga (con %w) y el
+// controller los traduce al status HTTP correspondiente usando errors.Is
+var (
+	// ErrNotFound indica que el item no existe
+	ErrNotFound = errors.New("item not found")
+
+	// ErrInvalidID indica que el ID recibido no tiene un formato válido
+	ErrInvalidID = errors.New("invalid item id")
+
+	// ErrInvalidItem indica que el item no cumple las reglas de negocio
+	ErrInvalidItem = errors.New("invalid item")
+
+	// ErrCacheMiss indica que la clave no está en cache (no es un error de la cache)
+	ErrCacheMiss = errors.New("item not found in cache")
+)
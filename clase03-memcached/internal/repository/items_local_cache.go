@@ -41,12 +41,19 @@ func (r ItemsLocalCacheRepository) GetByID(ctx context.Context, id string) (doma
 	return item, nil
 }
 
+// Update reemplaza el item sólo si ya estaba cacheado, conservando su TTL
+// (domain.ErrCacheMiss si no estaba)
 func (r ItemsLocalCacheRepository) Update(ctx context.Context, id string, item domain.Item) (domain.Item, error) {
-	//TODO implement me
-	panic("implement me")
+	if !r.client.Replace(id, item) {
+		return domain.Item{}, domain.ErrCacheMiss
+	}
+	return item, nil
 }
 
+// Delete invalida el item en la cache local (domain.ErrCacheMiss si no estaba cacheado)
 func (r ItemsLocalCacheRepository) Delete(ctx context.Context, id string) error {
-	//TODO implement me
-	panic("implement me")
+	if !r.client.Delete(id) {
+		return domain.ErrCacheMiss
+	}
+	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"clase03-memcached/internal/domain"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/bradfitz/gomemcache/memcache"
 	"time"
@@ -54,12 +55,34 @@ func (r MemcachedItemsRepository) GetByID(ctx context.Context, id string) (domai
 	return item, nil
 }
 
+// Update reemplaza el item sólo si ya estaba cacheado (domain.ErrCacheMiss si no)
 func (r MemcachedItemsRepository) Update(ctx context.Context, id string, item domain.Item) (domain.Item, error) {
-	//TODO implement me
-	panic("implement me")
+	bytes, err := json.Marshal(item)
+	if err != nil {
+		return domain.Item{}, fmt.Errorf("error marshalling item to JSON: %w", err)
+	}
+	err = r.client.Replace(&memcache.Item{
+		Key:        id,
+		Value:      bytes,
+		Expiration: int32(r.ttl.Seconds()),
+	})
+	if errors.Is(err, memcache.ErrNotStored) {
+		return domain.Item{}, domain.ErrCacheMiss
+	}
+	if err != nil {
+		return domain.Item{}, fmt.Errorf("error replacing item in memcached: %w", err)
+	}
+	return item, nil
 }
 
+// Delete invalida el item en memcached (domain.ErrCacheMiss si no estaba cacheado)
 func (r MemcachedItemsRepository) Delete(ctx context.Context, id string) error {
-	//TODO implement me
-	panic("implement me")
+	err := r.client.Delete(id)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return domain.ErrCacheMiss
+	}
+	if err != nil {
+		return fmt.Errorf("error deleting item from memcached: %w", err)
+	}
+	return nil
 }
@@ -5,11 +5,13 @@ import (
 	"clase03-memcached/internal/domain"
 	"context"
 	"errors"
+	"fmt"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -77,23 +79,102 @@ func (r *MongoItemsRepository) List(ctx context.Context) ([]domain.Item, error)
 // Create inserta un nuevo item en DB
 // Consigna 1: Validar name y price >= 0, agregar timestamps
 func (r *MongoItemsRepository) Create(ctx context.Context, item domain.Item) (domain.Item, error) {
-	return domain.Item{}, errors.New("TODO: implementar Create")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// 🕒 Timestamps en UTC, los setea el repository (no el cliente)
+	now := time.Now().UTC()
+	item.CreatedAt = now
+	item.UpdatedAt = now
+
+	// 🔄 Domain -> DAO, generando un ObjectID nuevo
+	daoItem := dao.FromDomain(item)
+	daoItem.ID = primitive.NewObjectID()
+
+	if _, err := r.col.InsertOne(ctx, daoItem); err != nil {
+		return domain.Item{}, fmt.Errorf("error inserting item in DB: %w", err)
+	}
+
+	return daoItem.ToDomain(), nil
 }
 
 // GetByID busca un item por su ID
 // Consigna 2: Validar que el ID sea un ObjectID válido
 func (r *MongoItemsRepository) GetByID(ctx context.Context, id string) (domain.Item, error) {
-	return domain.Item{}, errors.New("TODO: implementar GetByID")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Item{}, domain.ErrInvalidID
+	}
+
+	var daoItem dao.Item
+	if err := r.col.FindOne(ctx, bson.M{"_id": objectID}).Decode(&daoItem); err != nil {
+		// 🔍 ErrNoDocuments -> error de dominio para que el controller responda 404
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.Item{}, domain.ErrNotFound
+		}
+		return domain.Item{}, fmt.Errorf("error finding item in DB: %w", err)
+	}
+
+	return daoItem.ToDomain(), nil
 }
 
 // Update actualiza un item existente
 // Consigna 3: Update parcial + actualizar updatedAt
 func (r *MongoItemsRepository) Update(ctx context.Context, id string, item domain.Item) (domain.Item, error) {
-	return domain.Item{}, errors.New("TODO: implementar Update")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Item{}, domain.ErrInvalidID
+	}
+
+	// ✏️ $set solo sobre los campos editables: _id y created_at no se tocan
+	update := bson.M{
+		"$set": bson.M{
+			"name":       item.Name,
+			"price":      item.Price,
+			"updated_at": time.Now().UTC(),
+		},
+	}
+
+	// ReturnDocument(After) devuelve el documento ya actualizado
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var daoItem dao.Item
+	if err := r.col.FindOneAndUpdate(ctx, bson.M{"_id": objectID}, update, opts).Decode(&daoItem); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.Item{}, domain.ErrNotFound
+		}
+		return domain.Item{}, fmt.Errorf("error updating item in DB: %w", err)
+	}
+
+	return daoItem.ToDomain(), nil
 }
 
 // Delete elimina un item por ID
 // Consigna 4: Eliminar documento de DB
 func (r *MongoItemsRepository) Delete(ctx context.Context, id string) error {
-	return errors.New("TODO: implementar Delete")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrInvalidID
+	}
+
+	result, err := r.col.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return fmt.Errorf("error deleting item from DB: %w", err)
+	}
+
+	// DeletedCount == 0 significa que no había documento con ese ID
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 )
 
@@ -52,6 +53,10 @@ func (s *ItemsServiceImpl) List(ctx context.Context) ([]domain.Item, error) {
 // Create valida y crea un nuevo item
 // Consigna 1: Validar name no vacío y price >= 0
 func (s *ItemsServiceImpl) Create(ctx context.Context, item domain.Item) (domain.Item, error) {
+	if err := s.validateItem(item); err != nil {
+		return domain.Item{}, err
+	}
+
 	created, err := s.repository.Create(ctx, item)
 	if err != nil {
 		return domain.Item{}, fmt.Errorf("error creating item in repository: %w", err)
@@ -88,21 +93,43 @@ func (s *ItemsServiceImpl) GetByID(ctx context.Context, id string) (domain.Item,
 // Update actualiza un item existente
 // Consigna 3: Validar campos antes de actualizar
 func (s *ItemsServiceImpl) Update(ctx context.Context, id string, item domain.Item) (domain.Item, error) {
+	if err := s.validateItem(item); err != nil {
+		return domain.Item{}, err
+	}
+
+	updated, err := s.repository.Update(ctx, id, item)
+	if err != nil {
+		return domain.Item{}, fmt.Errorf("error updating item in repository: %w", err)
+	}
 
-	// TODO: Actualizar en DB
-	// TODO: Guardar en cache
+	// Reemplazamos la entrada de cache (si no estaba cacheado no hace nada)
+	// La DB ya se actualizó: un error de cache no cambia el resultado
+	_, err = s.cache.Update(ctx, id, updated)
+	s.logCacheError("update", id, err)
 
-	return domain.Item{}, errors.New("TODO: implementar Update")
+	return updated, nil
 }
 
 // Delete elimina un item por ID
 // Consigna 4: Validar ID antes de eliminar
 func (s *ItemsServiceImpl) Delete(ctx context.Context, id string) error {
+	if err := s.repository.Delete(ctx, id); err != nil {
+		return fmt.Errorf("error deleting item from repository: %w", err)
+	}
 
-	// TODO: Borrar de cache
-	// TODO: Borrar de DB
+	// 🗑️ Invalidamos la entrada de cache para no servir el item borrado
+	s.logCacheError("delete", id, s.cache.Delete(ctx, id))
 
-	return errors.New("TODO: implementar Delete")
+	return nil
+}
+
+// logCacheError registra un error de cache sin cortar la operación
+// La cache es una optimización: si falla se sigue con la DB. Los miss no se registran
+func (s *ItemsServiceImpl) logCacheError(op string, id string, err error) {
+	if err == nil || errors.Is(err, domain.ErrCacheMiss) {
+		return
+	}
+	log.Printf("⚠️ cache %s %s: %v", op, id, err)
 }
 
 // validateItem aplica reglas de negocio para validar un item
@@ -110,12 +137,12 @@ func (s *ItemsServiceImpl) Delete(ctx context.Context, id string) error {
 func (s *ItemsServiceImpl) validateItem(item domain.Item) error {
 	// 📝 Name es obligatorio y no puede estar vacío
 	if strings.TrimSpace(item.Name) == "" {
-		return errors.New("name is required and cannot be empty")
+		return fmt.Errorf("%w: name is required and cannot be empty", domain.ErrInvalidItem)
 	}
 
 	// 💰 Price debe ser >= 0 (productos gratis están permitidos)
 	if item.Price < 0 {
-		return errors.New("price must be greater than or equal to 0")
+		return fmt.Errorf("%w: price must be greater than or equal to 0", domain.ErrInvalidItem)
 	}
 
 	// ✅ Todas las validaciones pasaron
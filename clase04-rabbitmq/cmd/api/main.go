@@ -5,10 +5,14 @@ import (
 	"clase04-rabbitmq/internal/config"
 	"clase04-rabbitmq/internal/controllers"
 	"clase04-rabbitmq/internal/middleware"
+	"clase04-rabbitmq/internal/outbox"
+	"clase04-rabbitmq/internal/pagination"
 	"clase04-rabbitmq/internal/repository"
 	"clase04-rabbitmq/internal/services"
+	"clase04-rabbitmq/internal/worker"
 	"context"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"log"
 	"net/http"
 	"time"
@@ -18,6 +22,15 @@ func main() {
 	// 📋 Cargar configuración desde las variables de entorno
 	cfg := config.Load()
 
+	// 🔐 Con un secreto conocido cualquiera puede fabricar cursores: en release es obligatorio
+	if cfg.CursorSecret == "" || cfg.CursorSecret == config.InsecureCursorSecret {
+		if gin.Mode() == gin.ReleaseMode {
+			log.Fatalf("CURSOR_SECRET must be set to a random secret when GIN_MODE=release")
+		}
+		log.Printf("⚠️⚠️ CURSOR_SECRET is not set: pagination cursors are signed with an INSECURE development secret")
+		cfg.CursorSecret = config.InsecureCursorSecret
+	}
+
 	// 🏗️ Inicializar capas de la aplicación (Dependency Injection)
 	// Patrón: Repository -> Service -> Controller
 	// Cada capa tiene una responsabilidad específica
@@ -26,54 +39,148 @@ func main() {
 	ctx := context.Background()
 
 	// Capa de datos: maneja operaciones DB
-	itemsMongoRepo := repository.NewMongoItemsRepository(ctx, cfg.Mongo.URI, cfg.Mongo.DB, "items")
+	itemsMongoRepo := repository.NewMongoItemsRepository(ctx, cfg.Mongo.URI, cfg.Mongo.DB, "items", cfg.Mongo.AllowNoTransactions)
+
+	// 🧩 Cluster de Memcached: hashing consistente entre los nodos y health checks
+	// para sacar del anillo a los caídos (sus claves van a los otros nodos)
+	memcachedNodes := make([]clients.MemcachedNode, len(cfg.Memcached.Servers))
+	for i, server := range cfg.Memcached.Servers {
+		memcachedNodes[i] = clients.MemcachedNode{Addr: server.Addr, Weight: server.Weight}
+	}
+	memcachedCluster := clients.NewMemcachedCluster(memcachedNodes, clients.MemcachedClusterOptions{
+		HealthTimeout:     cfg.Memcached.HealthTimeout,
+		DeadAfterFailures: cfg.Memcached.DeadAfterFailures,
+	})
+	go memcachedCluster.Run(ctx, cfg.Memcached.HealthInterval)
 
 	// Capa de cache distribuida: maneja operaciones con Memcached
 	itemsMemcachedRepo := repository.NewMemcachedItemsRepository(
-		cfg.Memcached.Host,
-		cfg.Memcached.Port,
-		time.Duration(cfg.Memcached.TTLSeconds)*time.Second,
+		memcachedCluster,
+		repository.CacheOptions{
+			TTL:         time.Duration(cfg.Memcached.TTLSeconds) * time.Second,
+			Stale:       cfg.CacheStale,
+			NegativeTTL: cfg.CacheNegative,
+		},
 	)
 
-	// Capa de cache local: maneja operaciones con CCache
-	// itemsLocalCacheRepo := repository.NewItemsLocalCacheRepository(30 * time.Second)
-
 	// Inicializamos RabbitMQ para comunicar las novedades de escritura de items
 	itemsQueue := clients.NewRabbitMQClient(
 		cfg.RabbitMQ.Username,
 		cfg.RabbitMQ.Password,
-		cfg.RabbitMQ.QueueName,
+		cfg.RabbitMQ.Exchange,
 		cfg.RabbitMQ.Host,
 		cfg.RabbitMQ.Port,
+		clients.RabbitMQOptions{
+			Durable:        cfg.RabbitMQ.Durable,
+			Persistent:     cfg.RabbitMQ.Persistent,
+			Confirms:       cfg.RabbitMQ.Confirms,
+			ConfirmTimeout: cfg.RabbitMQ.ConfirmTimeout,
+			MaxAttempts:    cfg.RabbitMQ.MaxAttempts,
+			RetryDelay:     cfg.RabbitMQ.RetryDelay,
+		},
 	)
 
+	// 🗃️ La cola del worker se declara también desde la API: si el worker todavía
+	// no arrancó los eventos quedan en la cola en lugar de descartarse
+	if err := itemsQueue.DeclareQueue(cfg.RabbitMQ.QueueName, cfg.RabbitMQ.BindingKeys...); err != nil {
+		log.Fatalf("failed to declare queue %s: %v", cfg.RabbitMQ.QueueName, err)
+	}
+
+	// 📬 Outbox: los eventos se guardan en Mongo junto con el item (misma transacción)
+	// y el relay los publica en RabbitMQ en segundo plano
+	itemsOutbox := repository.NewMongoOutboxRepository(ctx, itemsMongoRepo.Database(), "items_outbox")
+	go outbox.NewRelay(itemsOutbox, itemsQueue, time.Second, 100).Run(ctx)
+
+	// Cache de items: Memcached (compartida), CCache (local, una por réplica)
+	// o las dos escalonadas: CCache (L1) -> Memcached (L2) -> Mongo
+	var itemsCache services.ItemsCache = itemsMemcachedRepo
+	if cfg.LocalCache.Enabled {
+		// Capa de cache local: maneja operaciones con CCache
+		itemsLocalCacheRepo := repository.NewItemsLocalCacheRepository(repository.CacheOptions{
+			TTL:         time.Duration(cfg.LocalCache.TTLSeconds) * time.Second,
+			Stale:       cfg.CacheStale,
+			NegativeTTL: cfg.CacheNegative,
+		})
+		itemsCache = itemsLocalCacheRepo
+		if cfg.LocalCache.Tiered {
+			itemsCache = repository.NewTieredItemsRepository(itemsLocalCacheRepo, itemsMemcachedRepo)
+		}
+
+		// 📡 Cada réplica escucha todos los eventos de items para mantener su
+		// cache local al día con las escrituras hechas en las otras réplicas
+		// (Memcached es compartida: la actualiza la réplica que escribe)
+		invalidationQueue := "items-cache-" + uuid.New().String()
+		if err := itemsQueue.DeclareBroadcastQueue(invalidationQueue); err != nil {
+			log.Fatalf("failed to declare queue %s: %v", invalidationQueue, err)
+		}
+		invalidations := worker.NewDispatcher()
+		worker.NewCacheInvalidator(itemsLocalCacheRepo).Register(invalidations)
+		go func() {
+			// Concurrency 1: los eventos se aplican en el orden en que llegan
+			err := itemsQueue.Consume(ctx, clients.ConsumeOptions{
+				Queue:         invalidationQueue,
+				Prefetch:      50,
+				Concurrency:   1,
+				DiscardFailed: true,
+			}, invalidations.Dispatch)
+			if err != nil {
+				log.Printf("⚠️ cache invalidation stopped: %v", err)
+			}
+		}()
+	}
+
 	// Capa de lógica de negocio: validaciones, transformaciones
-	itemService := services.NewItemsService(itemsMongoRepo, itemsMemcachedRepo, itemsQueue)
+	itemService := services.NewItemsService(itemsMongoRepo, itemsCache, itemsOutbox, itemsMongoRepo)
 
-	// Capa de controladores: maneja HTTP requests/responses
-	itemController := controllers.NewItemsController(&itemService)
+	// 🔑 Respuestas guardadas por Idempotency-Key (reintentos seguros de POST /items)
+	idempotencyKeys := repository.NewMongoIdempotencyRepository(ctx, itemsMongoRepo.Database(), "idempotency_keys", cfg.IdempotencyTTL)
 
-	// Cache (ejercicio: ajustar TTL y agregar "índice" de claves)
-	// cache := cache.NewMemcached(memAddr)
+	// Capa de controladores: maneja HTTP requests/responses
+	itemController := controllers.NewItemsController(&itemService, pagination.NewCursorCodec(cfg.CursorSecret))
 
 	// 🌐 Configurar router HTTP con Gin
 	router := gin.Default()
 
 	// Middleware: funciones que se ejecutan en cada request
 	router.Use(middleware.CORSMiddleware)
+	router.Use(middleware.CorrelationMiddleware)
+	router.Use(middleware.AdminMiddleware(cfg.AdminToken))
 
 	// 🏥 Health check endpoint
 	router.GET("/healthz", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// 📊 Estado de los nodos de Memcached (sólo administradores)
+	router.GET("/debug/memcached", func(c *gin.Context) {
+		if !middleware.IsAdmin(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Memcached stats require admin privileges"})
+			return
+		}
+		c.JSON(http.StatusOK, memcachedCluster.Stats())
+	})
+
 	// 📚 Rutas de Items API
-	// GET /items - listar todos los items (✅ implementado)
+	// GET /items - listar items (paginado, con filtros y orden)
 	router.GET("/items", itemController.GetItems)
 
-	// TODO: Implementar la lógica de estos endpoints (actualmente retornan 501)
-	// POST /items - crear nuevo item
-	router.POST("/items", itemController.CreateItem)
+	// POST/PUT/DELETE /items/batch - operaciones sobre varios items a la vez
+	// Ruta estática: tiene prioridad sobre /items/:id (y "batch" no es un ObjectID)
+	router.POST("/items/batch", itemController.CreateItems)
+	router.PUT("/items/batch", itemController.UpdateItems)
+	router.DELETE("/items/batch", itemController.DeleteItems)
+
+	// GET /items/export?format=csv|jsonl - descarga del catálogo completo
+	router.GET("/items/export", itemController.ExportItems)
+
+	// POST /items/import - carga masiva desde CSV o JSON Lines
+	router.POST("/items/import", itemController.ImportItems)
+
+	// GET /items/search?q= - búsqueda por texto ordenada por relevancia
+	router.GET("/items/search", itemController.SearchItems)
+
+	// POST /items - crear nuevo item (reintentable con el header Idempotency-Key)
+	router.POST("/items", middleware.IdempotencyMiddleware(idempotencyKeys), itemController.CreateItem)
 
 	// GET /items/:id - obtener item por ID
 	router.GET("/items/:id", itemController.GetItemByID)
@@ -81,9 +188,12 @@ func main() {
 	// PUT /items/:id - actualizar item existente
 	router.PUT("/items/:id", itemController.UpdateItem)
 
-	// DELETE /items/:id - eliminar item
+	// DELETE /items/:id - eliminar item (borrado lógico, ?purge=true lo borra definitivamente)
 	router.DELETE("/items/:id", itemController.DeleteItem)
 
+	// POST /items/:id/restore - recuperar item borrado lógicamente
+	router.POST("/items/:id/restore", itemController.RestoreItem)
+
 	// Configuración del server HTTP
 	srv := &http.Server{
 		Addr:              ":" + cfg.Port,
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"clase04-rabbitmq/internal/clients"
+	"clase04-rabbitmq/internal/config"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// Herramienta de administración de la dead-letter queue de los eventos de items
+//
+//	dlq list    [-n 20]  muestra los mensajes sin sacarlos de la DLQ
+//	dlq replay  [-n 0]   los devuelve a la cola para reprocesarlos (0 = todos)
+//	dlq purge            los borra definitivamente
+func main() {
+	// 🚩 Flags: -queue elige la cola (por defecto la del worker)
+	cfg := config.Load()
+	queue := flag.String("queue", cfg.RabbitMQ.QueueName, "queue whose dead letters are managed")
+	limit := flag.Int("n", 0, "max messages to list or replay (0 = all)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-queue name] [-n N] list|replay|purge\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// 📋 Misma configuración que la API: el exchange de descartados se deriva de RABBITMQ_EXCHANGE
+	itemsQueue := clients.NewRabbitMQClient(
+		cfg.RabbitMQ.Username,
+		cfg.RabbitMQ.Password,
+		cfg.RabbitMQ.Exchange,
+		cfg.RabbitMQ.Host,
+		cfg.RabbitMQ.Port,
+		clients.RabbitMQOptions{
+			Durable:        cfg.RabbitMQ.Durable,
+			Persistent:     cfg.RabbitMQ.Persistent,
+			Confirms:       cfg.RabbitMQ.Confirms,
+			ConfirmTimeout: cfg.RabbitMQ.ConfirmTimeout,
+			MaxAttempts:    cfg.RabbitMQ.MaxAttempts,
+			RetryDelay:     cfg.RabbitMQ.RetryDelay,
+		},
+	)
+
+	switch flag.Arg(0) {
+	case "list":
+		deadLetters, err := itemsQueue.InspectDeadLetters(ctx, *queue, *limit)
+		if err != nil {
+			log.Fatalf("Error listing dead letters: %v", err)
+		}
+		for _, d := range deadLetters {
+			event := d.Body
+			if d.Event.Type != "" {
+				event = d.Event.Type + " " + d.Event.Subject
+			}
+			log.Printf("%s  %s  retries=%d  %-50s %s",
+				d.Timestamp.Format(time.RFC3339), d.MessageID, d.Retries, event, d.Reason)
+		}
+		log.Printf("📭 %d dead letter(s) in %s.dlq", len(deadLetters), *queue)
+	case "replay":
+		replayed, err := itemsQueue.ReplayDeadLetters(ctx, *queue, *limit)
+		if err != nil {
+			log.Fatalf("Error replaying dead letters (%d replayed): %v", replayed, err)
+		}
+		log.Printf("🔁 %d message(s) sent back to %s", replayed, *queue)
+	case "purge":
+		purged, err := itemsQueue.PurgeDeadLetters(ctx, *queue)
+		if err != nil {
+			log.Fatalf("Error purging dead letters: %v", err)
+		}
+		log.Printf("🗑️ %d message(s) purged from %s.dlq", purged, *queue)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"clase04-rabbitmq/internal/config"
+	"clase04-rabbitmq/internal/migrations"
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	// 🚩 Flags: por defecto aplica las pendientes, -status solo las lista
+	status := flag.Bool("status", false, "list migrations and whether they were applied")
+	collection := flag.String("collection", "items", "items collection name")
+	flag.Parse()
+
+	// 📋 Misma configuración que la API (MONGO_URI, MONGO_DB)
+	cfg := config.Load()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	opt := options.Client().ApplyURI(cfg.Mongo.URI)
+	opt.SetServerSelectionTimeout(10 * time.Second)
+
+	client, err := mongo.Connect(ctx, opt)
+	if err != nil {
+		log.Fatalf("Error connecting to DB: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	if err := client.Ping(ctx, nil); err != nil {
+		log.Fatalf("Error pinging DB: %v", err)
+	}
+
+	runner := migrations.NewRunner(client.Database(cfg.Mongo.DB), migrations.Items(*collection))
+
+	if *status {
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			log.Fatalf("Error reading migrations status: %v", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			log.Printf("%3d  %-60s %s", s.Version, s.Description, state)
+		}
+		return
+	}
+
+	applied, err := runner.Up(ctx)
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+	log.Printf("✅ %d migration(s) applied", len(applied))
+}
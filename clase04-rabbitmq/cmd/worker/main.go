@@ -0,0 +1,107 @@
+package main
+
+import (
+	"clase04-rabbitmq/internal/clients"
+	"clase04-rabbitmq/internal/config"
+	"clase04-rabbitmq/internal/domain"
+	"clase04-rabbitmq/internal/repository"
+	"clase04-rabbitmq/internal/worker"
+	"context"
+	"expvar"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Worker que consume los eventos de items publicados por la API
+func main() {
+	// 📋 Cargar configuración desde las variables de entorno
+	cfg := config.Load()
+
+	// 🛑 Ctrl+C / docker stop cancelan el context y disparan el apagado ordenado
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	itemsQueue := clients.NewRabbitMQClient(
+		cfg.RabbitMQ.Username,
+		cfg.RabbitMQ.Password,
+		cfg.RabbitMQ.Exchange,
+		cfg.RabbitMQ.Host,
+		cfg.RabbitMQ.Port,
+		clients.RabbitMQOptions{
+			Durable:        cfg.RabbitMQ.Durable,
+			Persistent:     cfg.RabbitMQ.Persistent,
+			Confirms:       cfg.RabbitMQ.Confirms,
+			ConfirmTimeout: cfg.RabbitMQ.ConfirmTimeout,
+			MaxAttempts:    cfg.RabbitMQ.MaxAttempts,
+			RetryDelay:     cfg.RabbitMQ.RetryDelay,
+		},
+	)
+
+	// 🔀 La cola recibe sólo las routing keys que nos interesan (RABBITMQ_BINDING_KEYS)
+	if err := itemsQueue.DeclareQueue(cfg.RabbitMQ.QueueName, cfg.RabbitMQ.BindingKeys...); err != nil {
+		log.Fatalf("failed to declare queue %s: %v", cfg.RabbitMQ.QueueName, err)
+	}
+
+	// 🗄️ Registro de eventos procesados en Mongo (misma base que la API)
+	db := connectMongo(ctx, cfg.Mongo.URI, cfg.Mongo.DB)
+	processedEvents := repository.NewMongoProcessedEventsRepository(
+		ctx, db, "processed_events", cfg.RabbitMQ.QueueName, cfg.Worker.DedupeTTL)
+
+	// 📬 Handlers por type de evento (incluye la versión del schema)
+	dispatcher := worker.NewDispatcher()
+	for _, action := range []string{"create", "update", "delete", "restore", "purge"} {
+		dispatcher.Handle(domain.ItemEventType(action), worker.LogHandler)
+	}
+	dispatcher.Handle(domain.ItemEventType("update"), worker.UpdateLogHandler)
+
+	// 🔁 Los duplicados (redeliveries, reintentos del outbox) se descartan por ID
+	deduplicator := worker.NewDeduplicator(processedEvents, dispatcher.Dispatch)
+
+	// 📊 Métricas en http://<worker>:9090/debug/vars (clave "dedupe")
+	expvar.Publish("dedupe", expvar.Func(func() any { return deduplicator.Stats() }))
+	go func() {
+		if err := http.ListenAndServe(cfg.Worker.MetricsAddr, nil); err != nil {
+			log.Printf("⚠️ metrics server stopped: %v", err)
+		}
+	}()
+
+	log.Printf("👷 Worker consuming from %s %v (prefetch=%d, concurrency=%d)",
+		cfg.RabbitMQ.QueueName, cfg.RabbitMQ.BindingKeys, cfg.Worker.Prefetch, cfg.Worker.Concurrency)
+
+	// Bloquea hasta la señal de apagado y el fin de los mensajes en proceso
+	err := itemsQueue.Consume(ctx, clients.ConsumeOptions{
+		Queue:       cfg.RabbitMQ.QueueName,
+		Prefetch:    cfg.Worker.Prefetch,
+		Concurrency: cfg.Worker.Concurrency,
+	}, deduplicator.Handle)
+	if err != nil {
+		log.Fatalf("worker error: %v", err)
+	}
+
+	log.Printf("👋 Worker stopped (%+v)", deduplicator.Stats())
+}
+
+// connectMongo conecta a Mongo o termina el proceso
+func connectMongo(ctx context.Context, uri, dbName string) *mongo.Database {
+	opt := options.Client().ApplyURI(uri)
+	opt.SetServerSelectionTimeout(10 * time.Second)
+
+	client, err := mongo.Connect(ctx, opt)
+	if err != nil {
+		log.Fatalf("Error connecting to DB: %v", err)
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := client.Ping(pingCtx, nil); err != nil {
+		log.Fatalf("Error pinging DB: %v", err)
+	}
+	return client.Database(dbName)
+}
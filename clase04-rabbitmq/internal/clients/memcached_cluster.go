@@ -0,0 +1,313 @@
+package clients
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/bradfitz/gomemcache/memcache"
+	"hash/crc32"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// virtualNodesPerWeight es la cantidad de puntos en el anillo por unidad de peso
+	// Más puntos = reparto de claves más parejo entre nodos
+	virtualNodesPerWeight = 100
+
+	// maxMovedKeys acota las claves recordadas por nodo caído (ver MemcachedCluster)
+	maxMovedKeys = 10000
+)
+
+// MemcachedClusterOptions configura los health checks del cluster
+type MemcachedClusterOptions struct {
+	// HealthTimeout acota cada chequeo (conexión + respuesta). Default: 2s
+	HealthTimeout time.Duration
+	// DeadAfterFailures es la cantidad de chequeos fallidos seguidos para dar
+	// un nodo por caído. Default: 2
+	DeadAfterFailures int
+}
+
+// MemcachedNode es un servidor del cluster de Memcached
+type MemcachedNode struct {
+	Addr   string // host:puerto
+	Weight int    // Peso relativo: un nodo con peso 2 recibe el doble de claves
+}
+
+// MemcachedNodeStats es el estado de un nodo del cluster
+type MemcachedNodeStats struct {
+	Addr      string `json:"addr"`
+	Weight    int    `json:"weight"`
+	Alive     bool   `json:"alive"`
+	Picks     uint64 `json:"picks"`     // Operaciones enviadas al nodo
+	Failovers uint64 `json:"failovers"` // Operaciones que le tocaban pero fueron a otro (estaba caído)
+	Failures  uint64 `json:"failures"`  // Health checks fallidos
+	LastError string `json:"last_error,omitempty"`
+}
+
+// MemcachedCluster reparte las claves entre varios Memcached con hashing
+// consistente: agregar o sacar un nodo sólo mueve las claves de ese nodo
+// Implementa memcache.ServerSelector: las claves de un nodo caído van al
+// siguiente nodo vivo del anillo hasta que vuelva
+// Un nodo se da por caído después de DeadAfterFailures chequeos fallidos
+// seguidos (cada uno acotado por HealthTimeout). Cuando vuelve se vacía y las
+// claves que se movieron a otros nodos mientras estuvo caído se borran de ahí:
+// si se volviera a caer no se servirían esas copias viejas
+type MemcachedCluster struct {
+	nodes   []*memcachedNode
+	ring    []ringPoint // Ordenado por hash
+	options MemcachedClusterOptions
+}
+
+type memcachedNode struct {
+	MemcachedNode
+	addr nodeAddr
+
+	alive         atomic.Bool
+	failures      atomic.Int64 // Health checks fallidos seguidos
+	picks         atomic.Uint64
+	failovers     atomic.Uint64
+	checkFailures atomic.Uint64 // Health checks fallidos en total
+
+	mu        sync.Mutex
+	lastError string
+
+	// Claves de este nodo que fueron a otro mientras estaba caído (clave -> nodo)
+	// Pasado maxMovedKeys sólo se recuerdan los nodos, que se vacían enteros
+	moved         map[string]*memcachedNode
+	movedOverflow map[*memcachedNode]bool
+}
+
+type ringPoint struct {
+	hash uint32
+	node *memcachedNode
+}
+
+// nodeAddr es la dirección de un nodo sin resolver: gomemcache la resuelve
+// en cada conexión, así un contenedor que vuelve con otra IP se sigue encontrando
+type nodeAddr string
+
+func (a nodeAddr) Network() string { return "tcp" }
+func (a nodeAddr) String() string  { return string(a) }
+
+// NewMemcachedCluster arma el anillo con los nodos indicados (todos vivos al inicio)
+// Las opciones sin valor toman su default
+func NewMemcachedCluster(nodes []MemcachedNode, options MemcachedClusterOptions) *MemcachedCluster {
+	if options.HealthTimeout <= 0 {
+		options.HealthTimeout = 2 * time.Second
+	}
+	if options.DeadAfterFailures < 1 {
+		options.DeadAfterFailures = 2
+	}
+
+	cluster := &MemcachedCluster{options: options}
+	for _, n := range nodes {
+		if n.Weight < 1 {
+			n.Weight = 1
+		}
+		node := &memcachedNode{MemcachedNode: n, addr: nodeAddr(n.Addr)}
+		node.alive.Store(true)
+		cluster.nodes = append(cluster.nodes, node)
+
+		for i := 0; i < n.Weight*virtualNodesPerWeight; i++ {
+			cluster.ring = append(cluster.ring, ringPoint{
+				hash: crc32.ChecksumIEEE([]byte(n.Addr + "#" + strconv.Itoa(i))),
+				node: node,
+			})
+		}
+	}
+	sort.Slice(cluster.ring, func(i, j int) bool { return cluster.ring[i].hash < cluster.ring[j].hash })
+	return cluster
+}
+
+// PickServer elige el nodo de la clave: el primer punto del anillo con hash
+// mayor o igual al de la clave, salteando los nodos caídos
+func (c *MemcachedCluster) PickServer(key string) (net.Addr, error) {
+	if len(c.ring) == 0 {
+		return nil, memcache.ErrNoServers
+	}
+
+	hash := crc32.ChecksumIEEE([]byte(key))
+	start := sort.Search(len(c.ring), func(i int) bool { return c.ring[i].hash >= hash })
+	owner := c.ring[start%len(c.ring)].node
+
+	for i := 0; i < len(c.ring); i++ {
+		node := c.ring[(start+i)%len(c.ring)].node
+		if !node.alive.Load() {
+			continue
+		}
+		node.picks.Add(1)
+		if node != owner {
+			owner.failovers.Add(1)
+			owner.trackMoved(key, node)
+		}
+		return node.addr, nil
+	}
+	return nil, memcache.ErrNoServers
+}
+
+// Each recorre los nodos vivos (gomemcache lo usa en FlushAll y Ping)
+func (c *MemcachedCluster) Each(fn func(net.Addr) error) error {
+	for _, node := range c.nodes {
+		if !node.alive.Load() {
+			continue
+		}
+		if err := fn(node.addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats retorna el estado de cada nodo
+func (c *MemcachedCluster) Stats() []MemcachedNodeStats {
+	stats := make([]MemcachedNodeStats, len(c.nodes))
+	for i, node := range c.nodes {
+		node.mu.Lock()
+		lastError := node.lastError
+		node.mu.Unlock()
+
+		stats[i] = MemcachedNodeStats{
+			Addr:      node.Addr,
+			Weight:    node.Weight,
+			Alive:     node.alive.Load(),
+			Picks:     node.picks.Load(),
+			Failovers: node.failovers.Load(),
+			Failures:  node.checkFailures.Load(),
+			LastError: lastError,
+		}
+	}
+	return stats
+}
+
+// Run chequea los nodos cada interval hasta que se cancele el context
+// 🩺 Un nodo se da por caído después de DeadAfterFailures chequeos fallidos
+// seguidos y vuelve al anillo con el primer chequeo exitoso
+func (c *MemcachedCluster) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, node := range c.nodes {
+				c.check(node)
+			}
+		}
+	}
+}
+
+// check actualiza el estado de un nodo según su respuesta a "version"
+func (c *MemcachedCluster) check(node *memcachedNode) {
+	if err := memcachedCommand(node.Addr, c.options.HealthTimeout, "version", "VERSION"); err != nil {
+		node.checkFailures.Add(1)
+		node.mu.Lock()
+		node.lastError = err.Error()
+		node.mu.Unlock()
+
+		if node.failures.Add(1) == int64(c.options.DeadAfterFailures) {
+			node.alive.Store(false)
+			log.Printf("⚠️ memcached %s marked dead: %v", node.Addr, err)
+		}
+		return
+	}
+
+	node.failures.Store(0)
+	if node.alive.Load() {
+		return
+	}
+
+	// 🧹 Mientras estuvo afuera sus claves se escribieron en otros nodos: lo
+	// vaciamos antes de devolverlo al anillo para no servir datos viejos
+	if err := memcachedCommand(node.Addr, c.options.HealthTimeout, "flush_all", "OK"); err != nil {
+		log.Printf("⚠️ memcached %s is back but could not be flushed: %v", node.Addr, err)
+		return
+	}
+	node.alive.Store(true)
+	log.Printf("✅ memcached %s is back", node.Addr)
+
+	// 🧹 Las claves ya vuelven a este nodo: borramos las copias que quedaron en
+	// los otros (si no, reaparecerían viejas en la próxima caída)
+	node.mu.Lock()
+	moved, overflow := node.moved, node.movedOverflow
+	node.moved, node.movedOverflow = nil, nil
+	node.mu.Unlock()
+
+	byNode := make(map[*memcachedNode][]string)
+	for key, other := range moved {
+		if !overflow[other] {
+			byNode[other] = append(byNode[other], key)
+		}
+	}
+	for other, keys := range byNode {
+		cmds := make([]string, len(keys))
+		for i, key := range keys {
+			cmds[i] = "delete " + key + " noreply"
+		}
+		if err := memcachedCommand(other.Addr, c.options.HealthTimeout, strings.Join(cmds, "\r\n")+"\r\nversion", "VERSION"); err != nil {
+			log.Printf("⚠️ memcached %s: could not delete %d keys moved from %s: %v", other.Addr, len(keys), node.Addr, err)
+		}
+	}
+	for other := range overflow {
+		if err := memcachedCommand(other.Addr, c.options.HealthTimeout, "flush_all", "OK"); err != nil {
+			log.Printf("⚠️ memcached %s: could not flush keys moved from %s: %v", other.Addr, node.Addr, err)
+		}
+	}
+}
+
+// trackMoved recuerda que key (de este nodo) fue a other mientras estaba caído
+func (n *memcachedNode) trackMoved(key string, other *memcachedNode) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.moved[key]; ok || n.movedOverflow[other] {
+		return
+	}
+	if len(n.moved) >= maxMovedKeys {
+		if n.movedOverflow == nil {
+			n.movedOverflow = make(map[*memcachedNode]bool)
+		}
+		n.movedOverflow[other] = true
+		return
+	}
+	if n.moved == nil {
+		n.moved = make(map[string]*memcachedNode)
+	}
+	n.moved[key] = other
+}
+
+// memcachedCommand envía comandos del protocolo de texto de Memcached (separados
+// por \r\n) y verifica que la primera respuesta empiece con expected
+func memcachedCommand(addr string, timeout time.Duration, cmd string, expected string) error {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return fmt.Errorf("error connecting to memcached: %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return fmt.Errorf("error setting deadline: %w", err)
+	}
+
+	// Los errores nombran sólo el primer comando (ej.: "delete", no todas las claves)
+	name, _, _ := strings.Cut(cmd, " ")
+	if _, err := fmt.Fprintf(conn, "%s\r\n", cmd); err != nil {
+		return fmt.Errorf("error sending %s: %w", name, err)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("error reading %s response: %w", name, err)
+	}
+	if !strings.HasPrefix(line, expected) {
+		return fmt.Errorf("unexpected %s response %q", name, strings.TrimSpace(line))
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bradfitz/gomemcache/memcache"
+	"testing"
+)
+
+func TestMemcachedClusterPickServer(t *testing.T) {
+	nodes := []MemcachedNode{{Addr: "a:11211"}, {Addr: "b:11211"}, {Addr: "c:11211"}}
+
+	tests := []struct {
+		name    string
+		nodes   []MemcachedNode
+		dead    []string // Nodos marcados como caídos
+		wantErr error
+		check   func(t *testing.T, cluster *MemcachedCluster, key string, addr string)
+	}{
+		{
+			name:    "sin nodos",
+			wantErr: memcache.ErrNoServers,
+		},
+		{
+			name:  "todos vivos: siempre el mismo nodo para la clave",
+			nodes: nodes,
+			check: func(t *testing.T, cluster *MemcachedCluster, key string, addr string) {
+				again, err := cluster.PickServer(key)
+				if err != nil || again.String() != addr {
+					t.Errorf("PickServer(%q) = %v, %v; want %s", key, again, err, addr)
+				}
+			},
+		},
+		{
+			name:  "dueño caído: va a otro nodo vivo",
+			nodes: nodes,
+			dead:  []string{"b:11211"},
+			check: func(t *testing.T, cluster *MemcachedCluster, key string, addr string) {
+				if addr == "b:11211" {
+					t.Errorf("PickServer(%q) picked a dead node", key)
+				}
+			},
+		},
+		{
+			name:    "todos caídos",
+			nodes:   nodes,
+			dead:    []string{"a:11211", "b:11211", "c:11211"},
+			wantErr: memcache.ErrNoServers,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := NewMemcachedCluster(tt.nodes, MemcachedClusterOptions{})
+			for _, node := range cluster.nodes {
+				for _, dead := range tt.dead {
+					if node.Addr == dead {
+						node.alive.Store(false)
+					}
+				}
+			}
+
+			for i := 0; i < 100; i++ {
+				key := fmt.Sprintf("item:%d", i)
+				addr, err := cluster.PickServer(key)
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("PickServer(%q) error = %v, want %v", key, err, tt.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("PickServer(%q) error = %v", key, err)
+				}
+				tt.check(t, cluster, key, addr.String())
+			}
+		})
+	}
+}
+
+func TestMemcachedClusterFailoverKeepsOtherKeys(t *testing.T) {
+	cluster := NewMemcachedCluster([]MemcachedNode{{Addr: "a:11211"}, {Addr: "b:11211"}, {Addr: "c:11211"}}, MemcachedClusterOptions{})
+
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("item:%d", i)
+		addr, _ := cluster.PickServer(key)
+		before[key] = addr.String()
+	}
+
+	// Con b caído sólo se mueven sus claves (hashing consistente) y se recuerdan
+	dead := cluster.nodes[1]
+	dead.alive.Store(false)
+	for key, owner := range before {
+		addr, _ := cluster.PickServer(key)
+		switch {
+		case owner == dead.Addr && addr.String() == dead.Addr:
+			t.Errorf("key %q still picked the dead node", key)
+		case owner != dead.Addr && addr.String() != owner:
+			t.Errorf("key %q moved from %s to %s", key, owner, addr)
+		case owner == dead.Addr && dead.moved[key] == nil:
+			t.Errorf("key %q moved from the dead node but was not tracked", key)
+		}
+	}
+}
+
+func TestMemcachedClusterWeights(t *testing.T) {
+	cluster := NewMemcachedCluster([]MemcachedNode{{Addr: "a:11211", Weight: 3}, {Addr: "b:11211", Weight: 1}}, MemcachedClusterOptions{})
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		addr, _ := cluster.PickServer(fmt.Sprintf("item:%d", i))
+		counts[addr.String()]++
+	}
+
+	// Peso 3 a 1: a debería recibir cerca del 75% de las claves
+	if share := float64(counts["a:11211"]) / 10000; share < 0.65 || share > 0.85 {
+		t.Errorf("weighted node got %.0f%% of the keys, want ~75%%", share*100)
+	}
+}
@@ -1,65 +1,404 @@
 package clients
 
 import (
+	"clase04-rabbitmq/internal/domain"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/rabbitmq/amqp091-go"
 	"log"
+	"sync"
 	"time"
 )
 
 const (
-	encodingJSON = "application/json"
-	encodingUTF8 = "UTF-8"
+	encodingCloudEvents = "application/cloudevents+json"
+	encodingUTF8        = "UTF-8"
 )
 
+// RabbitMQOptions define la durabilidad de los eventos publicados
+type RabbitMQOptions struct {
+	Durable        bool          // Exchange y colas sobreviven a un reinicio del broker
+	Persistent     bool          // Los mensajes se escriben a disco (requiere cola durable)
+	Confirms       bool          // Publish espera la confirmación (ack) del broker
+	ConfirmTimeout time.Duration // Espera máxima por la confirmación
+	MaxAttempts    int           // Intentos de procesamiento antes de mandar el mensaje a la DLQ
+	RetryDelay     time.Duration // Espera entre reintentos de procesamiento
+}
+
 type RabbitMQClient struct {
-	connection *amqp091.Connection
-	channel    *amqp091.Channel
-	queue      *amqp091.Queue
+	connection *rabbitMQConnection
+	exchange   string
+	options    RabbitMQOptions
 }
 
-func NewRabbitMQClient(user, password, queueName, host, port string) *RabbitMQClient {
+// NewRabbitMQClient crea el cliente y conecta en segundo plano
+// Los eventos se publican en el exchange topic indicado con routing keys
+// items.created, items.updated, etc. (ver domain.ItemRoutingKey)
+// Si RabbitMQ no está disponible (o se cae después) el cliente reintenta solo:
+// mientras tanto Publish y Consume esperan la reconexión
+func NewRabbitMQClient(user, password, exchange, host, port string, options RabbitMQOptions) *RabbitMQClient {
 	connStr := fmt.Sprintf("amqp://%s:%s@%s:%s/", user, password, host, port) // 👈 %s
-	connection, err := amqp091.Dial(connStr)
+	connection := newRabbitMQConnection(connStr, func(channel *amqp091.Channel) error {
+		// El exchange se vuelve a declarar en cada reconexión (el broker pudo perderlo)
+		if err := channel.ExchangeDeclare(exchange, amqp091.ExchangeTopic, options.Durable, false, false, false, nil); err != nil {
+			return fmt.Errorf("error declaring exchange %s: %w", exchange, err)
+		}
+		// ✅ Modo confirm: el broker confirma cada mensaje publicado en este channel
+		if options.Confirms {
+			if err := channel.Confirm(false); err != nil {
+				return fmt.Errorf("error enabling publisher confirms: %w", err)
+			}
+		}
+		return nil
+	})
+	return &RabbitMQClient{connection: connection, exchange: exchange, options: options}
+}
+
+// DeclareQueue declara una cola y la suscribe al exchange con los patrones
+// indicados (ej.: "items.created", "items.*", "items.#")
+// Junto con la cola se declaran su cola de reintentos (<cola>.retry) y su
+// dead-letter queue (<cola>.dlq, ver rabbitmq_dead_letters.go)
+// La declaración se repite en cada reconexión
+// ⚠️ Cambiar Durable o la política de reintentos sobre una cola existente
+// falla (PRECONDITION_FAILED): hay que borrar la cola antes
+func (r RabbitMQClient) DeclareQueue(queueName string, bindingKeys ...string) error {
+	return r.connection.AddSetup(func(channel *amqp091.Channel) error {
+		if err := r.declareDeadLetters(channel, queueName); err != nil {
+			return err
+		}
+
+		// ☠️ Lo que se rechace sin reencolar va a la DLQ
+		args := amqp091.Table{
+			"x-dead-letter-exchange":    r.deadLetterExchange(),
+			"x-dead-letter-routing-key": queueName,
+		}
+		if _, err := channel.QueueDeclare(queueName, r.options.Durable, false, false, false, args); err != nil {
+			return fmt.Errorf("error declaring queue %s: %w", queueName, err)
+		}
+		for _, key := range bindingKeys {
+			if err := channel.QueueBind(queueName, key, r.exchange, false, nil); err != nil {
+				return fmt.Errorf("error binding queue %s to %s: %w", queueName, key, err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeclareBroadcastQueue declara una cola exclusiva de esta instancia que recibe
+// una copia de todos los eventos a través del exchange fanout <exchange>.fanout
+// (enlazado al exchange topic). Pensada para avisos que le interesan a cada
+// réplica por separado, como invalidar su cache local
+// La cola se borra sola al cerrarse la conexión: no acumula mensajes de
+// réplicas caídas. Consumirla con DiscardFailed (no tiene reintentos ni DLQ)
+func (r RabbitMQClient) DeclareBroadcastQueue(queueName string) error {
+	fanout := r.exchange + ".fanout"
+	return r.connection.AddSetup(func(channel *amqp091.Channel) error {
+		if err := channel.ExchangeDeclare(fanout, amqp091.ExchangeFanout, r.options.Durable, false, false, false, nil); err != nil {
+			return fmt.Errorf("error declaring exchange %s: %w", fanout, err)
+		}
+		// 🔀 Todo lo que llega al exchange topic se copia al fanout
+		if err := channel.ExchangeBind(fanout, "#", r.exchange, false, nil); err != nil {
+			return fmt.Errorf("error binding exchange %s to %s: %w", fanout, r.exchange, err)
+		}
+		if _, err := channel.QueueDeclare(queueName, false, true, true, false, nil); err != nil {
+			return fmt.Errorf("error declaring queue %s: %w", queueName, err)
+		}
+		if err := channel.QueueBind(queueName, "", fanout, false, nil); err != nil {
+			return fmt.Errorf("error binding queue %s to %s: %w", queueName, fanout, err)
+		}
+		return nil
+	})
+}
+
+// Publish envía un evento de items en formato CloudEvents (JSON estructurado)
+// El ID del evento es el MessageId: republicar el mismo evento (ej.: reintentos
+// del outbox) conserva el ID y los consumidores pueden descartar duplicados
+// Con Confirms retorna recién cuando el broker confirmó el mensaje: un nack
+// o una confirmación que no llega a tiempo se retornan como error
+func (r RabbitMQClient) Publish(ctx context.Context, event domain.CloudEvent) error {
+	bytes, err := json.Marshal(event)
 	if err != nil {
-		log.Fatalf("failed to connect to RabbitMQ: %v", err) // 👈 %v, no %w
+		return fmt.Errorf("error marshalling event to JSON: %w", err)
 	}
-	channel, err := connection.Channel()
-	if err != nil {
-		log.Fatalf("failed to open a channel: %v", err)
+
+	return r.publish(ctx, r.exchange, event.RoutingKey(), amqp091.Publishing{
+		ContentType:     encodingCloudEvents,
+		ContentEncoding: encodingUTF8,
+		DeliveryMode:    r.deliveryMode(),
+		MessageId:       event.ID,
+		CorrelationId:   event.CorrelationID,
+		Type:            event.Type,
+		Timestamp:       event.Time,
+		AppId:           "items-api",
+		Body:            bytes,
+	})
+}
+
+// publish envía un mensaje y espera la confirmación del broker (si está activa)
+func (r RabbitMQClient) publish(ctx context.Context, exchange string, routingKey string, publishing amqp091.Publishing) error {
+	// ⏳ Durante una reconexión esperamos el channel nuevo (hasta que venza ctx)
+	for {
+		channel, err := r.connection.Channel(ctx)
+		if err != nil {
+			return err
+		}
+
+		confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, false, false, publishing)
+		if errors.Is(err, amqp091.ErrClosed) {
+			// El channel se cerró entre que lo obtuvimos y publicamos: reintentamos
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("error publishing message to RabbitMQ: %w", err)
+			case <-time.After(100 * time.Millisecond):
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error publishing message to RabbitMQ: %w", err)
+		}
+		return r.waitConfirmation(ctx, confirmation, publishing.MessageId)
+	}
+}
+
+// waitConfirmation espera el ack del broker (nil si el channel no está en modo confirm)
+func (r RabbitMQClient) waitConfirmation(ctx context.Context, confirmation *amqp091.DeferredConfirmation, messageID string) error {
+	if confirmation == nil {
+		return nil
 	}
-	queue, err := channel.QueueDeclare(queueName, false, false, false, false, nil)
+
+	// ⏰ Timeout para no quedar esperando una confirmación que no llega
+	if r.options.ConfirmTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.options.ConfirmTimeout)
+		defer cancel()
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
 	if err != nil {
-		log.Fatalf("failed to declare a queue: %v", err)
+		return fmt.Errorf("error waiting confirmation for message %s: %w", messageID, err)
+	}
+	if !acked {
+		// Nack del broker o channel cerrado antes de confirmar
+		return fmt.Errorf("message %s was not confirmed by RabbitMQ", messageID)
+	}
+	return nil
+}
+
+// deliveryMode retorna el modo de entrega según la configuración
+func (r RabbitMQClient) deliveryMode() uint8 {
+	if r.options.Persistent {
+		return amqp091.Persistent
 	}
-	return &RabbitMQClient{connection: connection, channel: channel, queue: &queue}
+	return amqp091.Transient
 }
 
+// ConsumeOptions configura cómo se reciben los mensajes
+type ConsumeOptions struct {
+	Queue       string // Cola a consumir (declarada con DeclareQueue o DeclareBroadcastQueue)
+	Prefetch    int    // Mensajes sin ack que el broker entrega por adelantado
+	Concurrency int    // Mensajes que se procesan en paralelo
+
+	// DiscardFailed descarta los mensajes que fallan en lugar de reintentarlos
+	// o mandarlos a la DLQ (colas efímeras como las de DeclareBroadcastQueue)
+	DiscardFailed bool
+}
 
-func (r RabbitMQClient) Publish(ctx context.Context, action string, itemID string) error {
-	message := map[string]interface{}{
-		"action":  action,
-		"item_id": itemID,
+// EventHandler procesa un evento recibido
+// Los mensajes que no son CloudEvents válidos van directo a la DLQ
+// Si retorna error el mensaje se reintenta después de RetryDelay, hasta
+// MaxAttempts veces; después va a la dead-letter queue
+type EventHandler func(ctx context.Context, event domain.CloudEvent) error
+
+// Consume recibe los eventos de la cola y los pasa a handler con ack manual
+// Bloquea hasta que se cancele ctx: en ese momento deja de recibir mensajes
+// nuevos y espera a que terminen los que están en proceso
+// Si se pierde la conexión se vuelve a suscribir al reconectar (los mensajes
+// sin ack de la conexión caída los reentrega el broker)
+func (r RabbitMQClient) Consume(ctx context.Context, opts ConsumeOptions, handler EventHandler) error {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if opts.Prefetch < opts.Concurrency {
+		opts.Prefetch = opts.Concurrency
 	}
 
-	bytes, err := json.Marshal(message)
+	// Los handlers usan un context que no se cancela con ctx: los mensajes en
+	// proceso terminan aunque se haya pedido el apagado
+	handlerCtx := context.WithoutCancel(ctx)
+
+	for {
+		channel, err := r.connection.Channel(ctx)
+		if err != nil {
+			// Sólo falla si se canceló ctx: apagado normal
+			return nil
+		}
+
+		consumerTag, deliveries, err := r.subscribe(channel, opts)
+		if err != nil {
+			log.Printf("rabbitmq: %v, retrying", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < opts.Concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// El canal se cierra tras Cancel (después de entregar lo ya
+				// recibido) o al perderse la conexión
+				for delivery := range deliveries {
+					r.handleDelivery(handlerCtx, opts, delivery, handler)
+				}
+			}()
+		}
+		drained := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(drained)
+		}()
+
+		select {
+		case <-drained:
+			log.Printf("rabbitmq: consumer %s lost its channel, resubscribing", consumerTag)
+		case <-ctx.Done():
+			log.Printf("rabbitmq: stopping consumer %s, draining in-flight messages", consumerTag)
+			if err := channel.Cancel(consumerTag, false); err != nil && !errors.Is(err, amqp091.ErrClosed) {
+				return fmt.Errorf("error cancelling consumer: %w", err)
+			}
+			<-drained
+			return nil
+		}
+	}
+}
+
+// subscribe configura el prefetch y se suscribe a la cola
+func (r RabbitMQClient) subscribe(channel *amqp091.Channel, opts ConsumeOptions) (string, <-chan amqp091.Delivery, error) {
+	// 🚦 Prefetch: el broker no nos manda más de N mensajes sin ack
+	if err := channel.Qos(opts.Prefetch, 0, false); err != nil {
+		return "", nil, fmt.Errorf("error setting prefetch: %w", err)
+	}
+
+	consumerTag := "items-worker-" + uuid.New().String()
+	deliveries, err := channel.Consume(opts.Queue, consumerTag, false, false, false, false, nil)
 	if err != nil {
-		return fmt.Errorf("error marshalling message to JSON: %w", err)
+		return "", nil, fmt.Errorf("error consuming from RabbitMQ: %w", err)
 	}
+	return consumerTag, deliveries, nil
+}
 
-	if err := r.channel.PublishWithContext(ctx, "", r.queue.Name, false, false, amqp091.Publishing{
-		ContentType:     encodingJSON,
-		ContentEncoding: encodingUTF8,
-		DeliveryMode:    amqp091.Transient,
-		MessageId:       uuid.New().String(),
-		Timestamp:       time.Now().UTC(),
-		AppId:           "items-api",
-		Body:            bytes,
-	}); err != nil {
-		return fmt.Errorf("error publishing message to RabbitMQ: %w", err)
+// handleDelivery decodifica un mensaje, lo procesa y hace ack/nack
+func (r RabbitMQClient) handleDelivery(ctx context.Context, opts ConsumeOptions, delivery amqp091.Delivery, handler EventHandler) {
+	queueName := opts.Queue
+
+	event, err := decodeEvent(delivery.Body)
+	if err != nil && opts.DiscardFailed {
+		log.Printf("rabbitmq: discarding malformed message %s: %v", delivery.MessageId, err)
+		r.discard(delivery)
+		return
 	}
-	return nil
+	if err != nil {
+		// ☠️ Un mensaje que no se puede decodificar nunca va a andar: directo a la DLQ
+		log.Printf("rabbitmq: malformed message %s, dead-lettering: %v", delivery.MessageId, err)
+		r.deadLetter(ctx, queueName, delivery, err)
+		return
+	}
+
+	if err := handler(ctx, event); err != nil {
+		if opts.DiscardFailed {
+			log.Printf("rabbitmq: discarding message %s: %v", delivery.MessageId, err)
+			r.discard(delivery)
+			return
+		}
+
+		attempt := retryCount(delivery) + 1
+		if attempt >= r.options.MaxAttempts {
+			log.Printf("rabbitmq: message %s failed %d time(s), dead-lettering: %v", delivery.MessageId, attempt, err)
+			r.deadLetter(ctx, queueName, delivery, err)
+			return
+		}
+
+		log.Printf("rabbitmq: message %s failed (attempt %d/%d), retrying in %s: %v",
+			delivery.MessageId, attempt, r.options.MaxAttempts, r.options.RetryDelay, err)
+		r.retry(ctx, queueName, delivery, attempt)
+		return
+	}
+
+	if err := delivery.Ack(false); err != nil {
+		log.Printf("rabbitmq: error acking message %s: %v", delivery.MessageId, err)
+	}
+}
+
+// discard rechaza el mensaje sin reencolarlo
+func (r RabbitMQClient) discard(delivery amqp091.Delivery) {
+	if err := delivery.Nack(false, false); err != nil {
+		log.Printf("rabbitmq: error rejecting message %s: %v", delivery.MessageId, err)
+	}
+}
+
+// retry manda una copia del mensaje a la cola de reintentos y confirma el original
+// Al vencer el TTL de <cola>.retry el broker lo devuelve a la cola principal
+func (r RabbitMQClient) retry(ctx context.Context, queueName string, delivery amqp091.Delivery, attempt int) {
+	ctx, cancel := context.WithTimeout(ctx, republishTimeout)
+	defer cancel()
+
+	publishing := republishing(delivery)
+	publishing.Headers[retryCountHeader] = int64(attempt)
+
+	if err := r.publish(ctx, "", retryQueueName(queueName), publishing); err != nil {
+		// No pudimos programar el reintento: lo devolvemos a la cola tal cual
+		log.Printf("rabbitmq: error scheduling retry of message %s: %v", delivery.MessageId, err)
+		if err := delivery.Nack(false, true); err != nil {
+			log.Printf("rabbitmq: error rejecting message %s: %v", delivery.MessageId, err)
+		}
+		return
+	}
+
+	if err := delivery.Ack(false); err != nil {
+		log.Printf("rabbitmq: error acking message %s: %v", delivery.MessageId, err)
+	}
+}
+
+// deadLetter manda el mensaje a la DLQ guardando el motivo en un header
+func (r RabbitMQClient) deadLetter(ctx context.Context, queueName string, delivery amqp091.Delivery, cause error) {
+	ctx, cancel := context.WithTimeout(ctx, republishTimeout)
+	defer cancel()
+
+	publishing := republishing(delivery)
+	publishing.Headers[errorHeader] = cause.Error()
+
+	if err := r.publish(ctx, r.deadLetterExchange(), queueName, publishing); err != nil {
+		// Sin el header: el broker lo manda a la DLQ por el x-dead-letter-exchange de la cola
+		log.Printf("rabbitmq: error dead-lettering message %s, rejecting it: %v", delivery.MessageId, err)
+		if err := delivery.Nack(false, false); err != nil {
+			log.Printf("rabbitmq: error rejecting message %s: %v", delivery.MessageId, err)
+		}
+		return
+	}
+
+	if err := delivery.Ack(false); err != nil {
+		log.Printf("rabbitmq: error acking message %s: %v", delivery.MessageId, err)
+	}
+}
+
+// decodeEvent decodifica y valida un CloudEvent
+func decodeEvent(body []byte) (domain.CloudEvent, error) {
+	var event domain.CloudEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return domain.CloudEvent{}, fmt.Errorf("error decoding event: %w", err)
+	}
+	if event.SpecVersion != domain.CloudEventsSpecVersion {
+		return domain.CloudEvent{}, fmt.Errorf("unsupported CloudEvents specversion %q", event.SpecVersion)
+	}
+	if event.ID == "" || event.Type == "" || event.Source == "" {
+		return domain.CloudEvent{}, errors.New("event is missing id, type or source")
+	}
+	return event, nil
 }
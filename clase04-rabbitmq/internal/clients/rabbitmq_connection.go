@@ -0,0 +1,181 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"github.com/rabbitmq/amqp091-go"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// reconnectMinBackoff / reconnectMaxBackoff acotan la espera entre intentos de conexión
+	reconnectMinBackoff = 500 * time.Millisecond
+	reconnectMaxBackoff = 30 * time.Second
+)
+
+// setupFunc declara parte de la topología (exchanges, colas, bindings) en un channel
+type setupFunc func(channel *amqp091.Channel) error
+
+// rabbitMQConnection mantiene viva la conexión con RabbitMQ
+// Si el broker se reinicia o se cae la red, vuelve a conectar con backoff
+// exponencial, reabre el channel y vuelve a declarar la topología (setups)
+type rabbitMQConnection struct {
+	url string
+
+	mu      sync.RWMutex
+	setups  []setupFunc
+	channel *amqp091.Channel // nil mientras se está reconectando
+	ready   chan struct{}    // Se cierra cuando hay un channel disponible
+}
+
+// newRabbitMQConnection arranca la conexión en segundo plano (no bloquea ni
+// mata el proceso si el broker todavía no está disponible)
+func newRabbitMQConnection(url string, setup setupFunc) *rabbitMQConnection {
+	c := &rabbitMQConnection{
+		url:    url,
+		setups: []setupFunc{setup},
+		ready:  make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+// AddSetup agrega topología que se declara ahora (si hay conexión) y en cada reconexión
+func (c *rabbitMQConnection) AddSetup(setup setupFunc) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.channel != nil {
+		if err := setup(c.channel); err != nil {
+			// No la guardamos: fallaría también en cada reconexión
+			return err
+		}
+	}
+	// Si no hay conexión se declara al conectar
+	c.setups = append(c.setups, setup)
+	return nil
+}
+
+// Channel retorna el channel actual, esperando la reconexión si hace falta
+// La espera está acotada por ctx
+func (c *rabbitMQConnection) Channel(ctx context.Context) (*amqp091.Channel, error) {
+	for {
+		c.mu.RLock()
+		channel, ready := c.channel, c.ready
+		c.mu.RUnlock()
+
+		if channel != nil && !channel.IsClosed() {
+			return channel, nil
+		}
+		if channel != nil {
+			// Se cerró pero run todavía no se enteró: sin esto ready sigue
+			// cerrado y el loop gira sin esperar
+			ready = c.lost(channel)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("error waiting for RabbitMQ connection: %w", ctx.Err())
+		case <-ready:
+		}
+	}
+}
+
+// run conecta, espera a que se cierre la conexión o el channel y vuelve a empezar
+func (c *rabbitMQConnection) run() {
+	attempt := 0
+	for {
+		connection, channel, err := c.connect()
+		if err != nil {
+			wait := reconnectBackoff(attempt)
+			attempt++
+			log.Printf("rabbitmq: connection attempt %d failed, retrying in %s: %v", attempt, wait, err)
+			time.Sleep(wait)
+			continue
+		}
+
+		// 👂 Registramos los avisos de cierre antes de publicar el channel
+		connectionClosed := connection.NotifyClose(make(chan *amqp091.Error, 1))
+		channelClosed := channel.NotifyClose(make(chan *amqp091.Error, 1))
+
+		// La topología se declara con el lock tomado: un AddSetup concurrente
+		// no puede quedar afuera
+		c.mu.Lock()
+		if err := c.declare(channel); err != nil {
+			c.mu.Unlock()
+			_ = connection.Close()
+			wait := reconnectBackoff(attempt)
+			attempt++
+			log.Printf("rabbitmq: topology setup failed, retrying in %s: %v", wait, err)
+			time.Sleep(wait)
+			continue
+		}
+		c.channel = channel
+		close(c.ready)
+		c.mu.Unlock()
+		attempt = 0
+		log.Printf("rabbitmq: connected")
+
+		var cause *amqp091.Error
+		select {
+		case cause = <-connectionClosed:
+		case cause = <-channelClosed:
+		}
+		log.Printf("rabbitmq: connection lost, reconnecting: %v", cause)
+		c.lost(channel)
+
+		// Si sólo se cerró el channel, la conexión sigue abierta: la cerramos
+		_ = connection.Close()
+	}
+}
+
+// lost saca el channel cerrado (si sigue siendo el actual) y retorna el ready
+// a esperar: se cierra recién cuando run vuelva a conectar
+func (c *rabbitMQConnection) lost(channel *amqp091.Channel) chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.channel == channel {
+		c.channel = nil
+		c.ready = make(chan struct{})
+	}
+	return c.ready
+}
+
+// connect abre conexión y channel
+func (c *rabbitMQConnection) connect() (*amqp091.Connection, *amqp091.Channel, error) {
+	connection, err := amqp091.Dial(c.url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error connecting to RabbitMQ: %w", err)
+	}
+	channel, err := connection.Channel()
+	if err != nil {
+		_ = connection.Close()
+		return nil, nil, fmt.Errorf("error opening channel: %w", err)
+	}
+	return connection, channel, nil
+}
+
+// declare ejecuta todos los setups en el channel (requiere c.mu tomado)
+func (c *rabbitMQConnection) declare(channel *amqp091.Channel) error {
+	for _, setup := range c.setups {
+		if err := setup(channel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconnectBackoff es exponencial: 0.5s, 1s, 2s... hasta reconnectMaxBackoff
+func reconnectBackoff(attempt int) time.Duration {
+	if attempt > 10 {
+		return reconnectMaxBackoff
+	}
+	wait := reconnectMinBackoff << attempt
+	if wait > reconnectMaxBackoff {
+		return reconnectMaxBackoff
+	}
+	return wait
+}
@@ -0,0 +1,246 @@
+package clients
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"context"
+	"fmt"
+	"github.com/rabbitmq/amqp091-go"
+	"time"
+)
+
+const (
+	// retryCountHeader cuenta los reintentos de procesamiento de un mensaje
+	retryCountHeader = "x-retry-count"
+
+	// errorHeader guarda el último error de procesamiento de un mensaje en la DLQ
+	errorHeader = "x-last-error"
+
+	// republishTimeout acota el envío a la cola de reintentos o a la DLQ
+	republishTimeout = 10 * time.Second
+)
+
+// DeadLetter es un mensaje de la dead-letter queue
+type DeadLetter struct {
+	MessageID string
+	Event     domain.CloudEvent // Vacío si el mensaje no se pudo decodificar
+	Body      string
+	Reason    string // Último error de procesamiento (o motivo del broker)
+	Retries   int
+	Timestamp time.Time
+}
+
+// deadLetterExchange es el exchange (direct) que recibe los mensajes descartados
+func (r RabbitMQClient) deadLetterExchange() string {
+	return r.exchange + ".dlx"
+}
+
+// deadLetterQueueName es la DLQ de una cola (ej.: items-news.dlq)
+func deadLetterQueueName(queueName string) string {
+	return queueName + ".dlq"
+}
+
+// retryQueueName es la cola de espera de reintentos de una cola (ej.: items-news.retry)
+func retryQueueName(queueName string) string {
+	return queueName + ".retry"
+}
+
+// declareDeadLetters declara el exchange de descartados, la DLQ y la cola de reintentos
+//
+//	items-news --(error, MaxAttempts agotados)--> items.dlx --> items-news.dlq
+//	items-news --(error)--> items-news.retry --(TTL = RetryDelay)--> items-news
+func (r RabbitMQClient) declareDeadLetters(channel *amqp091.Channel, queueName string) error {
+	if err := channel.ExchangeDeclare(r.deadLetterExchange(), amqp091.ExchangeDirect, r.options.Durable, false, false, false, nil); err != nil {
+		return fmt.Errorf("error declaring exchange %s: %w", r.deadLetterExchange(), err)
+	}
+
+	dlq := deadLetterQueueName(queueName)
+	if _, err := channel.QueueDeclare(dlq, r.options.Durable, false, false, false, nil); err != nil {
+		return fmt.Errorf("error declaring queue %s: %w", dlq, err)
+	}
+	if err := channel.QueueBind(dlq, queueName, r.deadLetterExchange(), false, nil); err != nil {
+		return fmt.Errorf("error binding queue %s: %w", dlq, err)
+	}
+
+	// ⏰ Cola sin consumidores: los mensajes esperan RetryDelay y el broker los
+	// devuelve a la cola principal (por el default exchange)
+	retryQueue := retryQueueName(queueName)
+	args := amqp091.Table{
+		"x-message-ttl":             max(r.options.RetryDelay.Milliseconds(), 0),
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": queueName,
+	}
+	if _, err := channel.QueueDeclare(retryQueue, r.options.Durable, false, false, false, args); err != nil {
+		return fmt.Errorf("error declaring queue %s: %w", retryQueue, err)
+	}
+	return nil
+}
+
+// InspectDeadLetters lista hasta limit mensajes de la DLQ de una cola sin sacarlos
+// (limit <= 0 lista todos)
+func (r RabbitMQClient) InspectDeadLetters(ctx context.Context, queueName string, limit int) ([]DeadLetter, error) {
+	channel, err := r.connection.Channel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := r.getDeadLetters(channel, queueName, limit)
+
+	// 🔙 Todo lo leído vuelve a la DLQ
+	for _, delivery := range deliveries {
+		if nackErr := delivery.Nack(false, true); nackErr != nil && err == nil {
+			err = fmt.Errorf("error returning message %s to the DLQ: %w", delivery.MessageId, nackErr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters := make([]DeadLetter, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deadLetters = append(deadLetters, toDeadLetter(delivery))
+	}
+	return deadLetters, nil
+}
+
+// ReplayDeadLetters devuelve hasta limit mensajes de la DLQ a su cola, con el
+// contador de reintentos en cero (limit <= 0 devuelve todos)
+// Retorna cuántos mensajes se devolvieron
+func (r RabbitMQClient) ReplayDeadLetters(ctx context.Context, queueName string, limit int) (int, error) {
+	channel, err := r.connection.Channel(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	deliveries, err := r.getDeadLetters(channel, queueName, limit)
+	if err != nil {
+		for _, delivery := range deliveries {
+			_ = delivery.Nack(false, true)
+		}
+		return 0, err
+	}
+
+	replayed := 0
+	for i, delivery := range deliveries {
+		publishing := republishing(delivery)
+		delete(publishing.Headers, retryCountHeader)
+		delete(publishing.Headers, errorHeader)
+
+		// Directo a la cola (default exchange): no se entrega a otros consumidores del exchange
+		if err := r.publish(ctx, "", queueName, publishing); err != nil {
+			for _, pending := range deliveries[i:] {
+				_ = pending.Nack(false, true)
+			}
+			return replayed, fmt.Errorf("error replaying message %s: %w", delivery.MessageId, err)
+		}
+		if err := delivery.Ack(false); err != nil {
+			return replayed, fmt.Errorf("error removing message %s from the DLQ: %w", delivery.MessageId, err)
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// PurgeDeadLetters borra todos los mensajes de la DLQ de una cola y retorna cuántos eran
+func (r RabbitMQClient) PurgeDeadLetters(ctx context.Context, queueName string) (int, error) {
+	channel, err := r.connection.Channel(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := channel.QueuePurge(deadLetterQueueName(queueName), false)
+	if err != nil {
+		return 0, fmt.Errorf("error purging %s: %w", deadLetterQueueName(queueName), err)
+	}
+	return purged, nil
+}
+
+// getDeadLetters saca (sin ack) hasta limit mensajes de la DLQ
+// Sólo se leen los mensajes que había al empezar: lo que llegue mientras tanto
+// (ej.: un replay que vuelve a fallar) no se lee dos veces
+func (r RabbitMQClient) getDeadLetters(channel *amqp091.Channel, queueName string, limit int) ([]amqp091.Delivery, error) {
+	dlq := deadLetterQueueName(queueName)
+	queue, err := channel.QueueDeclarePassive(dlq, r.options.Durable, false, false, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", dlq, err)
+	}
+
+	count := queue.Messages
+	if limit > 0 && limit < count {
+		count = limit
+	}
+
+	deliveries := make([]amqp091.Delivery, 0, count)
+	for len(deliveries) < count {
+		delivery, ok, err := channel.Get(dlq, false)
+		if err != nil {
+			return deliveries, fmt.Errorf("error reading %s: %w", dlq, err)
+		}
+		if !ok {
+			break
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// republishing copia un mensaje recibido para volver a publicarlo, con todas
+// sus propiedades (los headers se copian: el caller los puede modificar)
+// ⚠️ UserId se conserva: el broker lo valida contra el usuario de la conexión
+func republishing(delivery amqp091.Delivery) amqp091.Publishing {
+	headers := amqp091.Table{}
+	for k, v := range delivery.Headers {
+		headers[k] = v
+	}
+	return amqp091.Publishing{
+		Headers:         headers,
+		ContentType:     delivery.ContentType,
+		ContentEncoding: delivery.ContentEncoding,
+		DeliveryMode:    delivery.DeliveryMode,
+		Priority:        delivery.Priority,
+		CorrelationId:   delivery.CorrelationId,
+		ReplyTo:         delivery.ReplyTo,
+		Expiration:      delivery.Expiration,
+		MessageId:       delivery.MessageId,
+		Timestamp:       delivery.Timestamp,
+		Type:            delivery.Type,
+		UserId:          delivery.UserId,
+		AppId:           delivery.AppId,
+		Body:            delivery.Body,
+	}
+}
+
+// retryCount lee el header de reintentos (0 si no existe)
+func retryCount(delivery amqp091.Delivery) int {
+	switch v := delivery.Headers[retryCountHeader].(type) {
+	case int64:
+		return int(v)
+	case int32:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// toDeadLetter arma la vista de un mensaje de la DLQ
+func toDeadLetter(delivery amqp091.Delivery) DeadLetter {
+	deadLetter := DeadLetter{
+		MessageID: delivery.MessageId,
+		Body:      string(delivery.Body),
+		Retries:   retryCount(delivery),
+		Timestamp: delivery.Timestamp,
+	}
+	if event, err := decodeEvent(delivery.Body); err == nil {
+		deadLetter.Event = event
+	}
+
+	if reason, ok := delivery.Headers[errorHeader].(string); ok {
+		deadLetter.Reason = reason
+	} else if deaths, ok := delivery.Headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+		// Descartado por el broker: el motivo está en x-death (rejected, expired...)
+		if death, ok := deaths[0].(amqp091.Table); ok {
+			deadLetter.Reason, _ = death["reason"].(string)
+		}
+	}
+	return deadLetter
+}
@@ -0,0 +1,55 @@
+package clients
+
+import (
+	"github.com/rabbitmq/amqp091-go"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRepublishing(t *testing.T) {
+	delivery := amqp091.Delivery{
+		Headers:         amqp091.Table{"x-retry-count": int64(2)},
+		ContentType:     "application/cloudevents+json",
+		ContentEncoding: "utf-8",
+		DeliveryMode:    amqp091.Persistent,
+		Priority:        5,
+		CorrelationId:   "corr-1",
+		ReplyTo:         "replies",
+		Expiration:      "60000",
+		MessageId:       "event-1",
+		Timestamp:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Type:            "items.created.v1",
+		UserId:          "admin",
+		AppId:           "api",
+		Body:            []byte(`{"id":"event-1"}`),
+	}
+
+	want := amqp091.Publishing{
+		Headers:         amqp091.Table{"x-retry-count": int64(2)},
+		ContentType:     delivery.ContentType,
+		ContentEncoding: delivery.ContentEncoding,
+		DeliveryMode:    delivery.DeliveryMode,
+		Priority:        delivery.Priority,
+		CorrelationId:   delivery.CorrelationId,
+		ReplyTo:         delivery.ReplyTo,
+		Expiration:      delivery.Expiration,
+		MessageId:       delivery.MessageId,
+		Timestamp:       delivery.Timestamp,
+		Type:            delivery.Type,
+		UserId:          delivery.UserId,
+		AppId:           delivery.AppId,
+		Body:            delivery.Body,
+	}
+
+	got := republishing(delivery)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("republishing() = %+v, want %+v", got, want)
+	}
+
+	// Los headers son una copia: modificarlos no cambia el mensaje original
+	got.Headers["x-retry-count"] = int64(3)
+	if delivery.Headers["x-retry-count"] != int64(2) {
+		t.Errorf("republishing() shares headers with the delivery")
+	}
+}
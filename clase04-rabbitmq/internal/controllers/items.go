@@ -2,8 +2,14 @@ package controllers
 
 import (
 	"clase04-rabbitmq/internal/domain"
+	"clase04-rabbitmq/internal/middleware"
+	"clase04-rabbitmq/internal/pagination"
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,8 +18,8 @@ import (
 // Capa intermedia entre Controllers (HTTP) y Repository (datos)
 // Responsabilidades: validaciones, transformaciones, reglas de negocio
 type ItemsService interface {
-	// List retorna todos los items (sin filtros por ahora)
-	List(ctx context.Context) ([]domain.Item, error)
+	// List retorna una página de items filtrada y ordenada
+	List(ctx context.Context, query domain.ItemsQuery) (domain.ItemsPage, error)
 
 	// Create valida y crea un nuevo item
 	Create(ctx context.Context, item domain.Item) (domain.Item, error)
@@ -21,11 +27,32 @@ type ItemsService interface {
 	// GetByID obtiene un item por su ID
 	GetByID(ctx context.Context, id string) (domain.Item, error)
 
-	// Update actualiza un item existente
+	// Update actualiza un item existente (item.Version = versión esperada, 0 = sin chequeo)
 	Update(ctx context.Context, id string, item domain.Item) (domain.Item, error)
 
-	// Delete elimina un item por ID
-	Delete(ctx context.Context, id string) error
+	// Delete elimina un item por ID (version = versión esperada, 0 = sin chequeo)
+	Delete(ctx context.Context, id string, version int64) error
+
+	// Restore recupera un item borrado lógicamente
+	Restore(ctx context.Context, id string) (domain.Item, error)
+
+	// Purge elimina un item definitivamente
+	Purge(ctx context.Context, id string, version int64) error
+
+	// Search busca items por texto ordenados por relevancia
+	Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchHit, error)
+
+	// CreateBatch, UpdateBatch y DeleteBatch operan sobre varios items a la vez
+	// y retornan un resultado por item (éxito o error)
+	CreateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error)
+	UpdateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error)
+	DeleteBatch(ctx context.Context, refs []domain.ItemRef) ([]domain.BatchResult, error)
+
+	// Import hace upsert de un lote de filas importadas
+	Import(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error)
+
+	// Export recorre el catálogo completo llamando a fn por cada item
+	Export(ctx context.Context, fn func(domain.Item) error) error
 }
 
 // ItemsController maneja las peticiones HTTP para Items
@@ -35,59 +62,444 @@ type ItemsService interface {
 // - Llamar al service correspondiente
 // - Retornar respuesta HTTP adecuada
 type ItemsController struct {
-	service ItemsService // Inyección de dependencia
+	service ItemsService            // Inyección de dependencia
+	cursors *pagination.CursorCodec // Firma/valida los cursores de ?after=
 }
 
 // NewItemsController crea una nueva instancia del controller
-func NewItemsController(itemsService ItemsService) *ItemsController {
+func NewItemsController(itemsService ItemsService, cursors *pagination.CursorCodec) *ItemsController {
 	return &ItemsController{
 		service: itemsService,
+		cursors: cursors,
 	}
 }
 
-// GetItems maneja GET /items - Lista todos los items
-// ✅ IMPLEMENTADO - Ejemplo para que los estudiantes entiendan el patrón
+// GetItems maneja GET /items - Lista items con paginación, orden y filtros
+// Query params: page, limit, sort (name|price|created_at|updated_at), order (asc|desc),
+// name (substring), min_price, max_price, after (cursor devuelto en next_cursor),
+// include_deleted (true para incluir items borrados lógicamente, solo admins)
+// Ej.: GET /items?page=2&limit=10&sort=price&order=desc&name=coffee&max_price=20
 func (c *ItemsController) GetItems(ctx *gin.Context) {
+	// 📥 Parsear query params al objeto de consulta
+	query, err := c.parseItemsQuery(ctx)
+	if err != nil {
+		respondError(ctx, "Invalid query parameters", err)
+		return
+	}
+
+	// 🔒 Los items borrados solo los ven los administradores
+	if query.IncludeDeleted && !middleware.IsAdmin(ctx) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Listing deleted items requires admin privileges"})
+		return
+	}
+
 	// 🔍 Llamar al service para obtener los datos
-	items, err := c.service.List(ctx.Request.Context())
+	page, err := c.service.List(ctx.Request.Context(), query)
 	if err != nil {
-		// ❌ Error interno del servidor
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch items",
-			"details": err.Error(),
-		})
+		respondError(ctx, "Failed to fetch items", err)
+		return
+	}
+
+	response := gin.H{
+		"items": page.Items,
+		"count": len(page.Items),
+		"total": page.Total,
+		"limit": page.Limit,
+		"next":  nil,
+	}
+
+	if query.After == nil {
+		response["page"] = page.Page
+	}
+
+	// 🧭 Cursor opaco y firmado para seguir recorriendo con ?after=
+	if page.NextCursor != nil {
+		cursor, err := c.cursors.Encode(*page.NextCursor)
+		if err != nil {
+			respondError(ctx, "Failed to fetch items", err)
+			return
+		}
+		response["next_cursor"] = cursor
+	}
+
+	// 🔗 Link a la siguiente página (mismos filtros): por cursor si hay, si no page+1
+	if page.HasMore {
+		nextURL := *ctx.Request.URL
+		values := nextURL.Query()
+		values.Set("limit", strconv.Itoa(page.Limit))
+		if cursor, ok := response["next_cursor"]; ok {
+			values.Del("page")
+			values.Set("after", cursor.(string))
+		} else {
+			values.Set("page", strconv.Itoa(page.Page+1))
+		}
+		nextURL.RawQuery = values.Encode()
+		response["next"] = nextURL.RequestURI()
+	}
+
+	// ✅ Respuesta exitosa con los datos y metadata de paginación
+	ctx.JSON(http.StatusOK, response)
+}
+
+// parseItemsQuery arma un domain.ItemsQuery a partir de los query params
+// Los defaults y límites los aplica el service, acá solo se valida el formato
+func (c *ItemsController) parseItemsQuery(ctx *gin.Context) (domain.ItemsQuery, error) {
+	var query domain.ItemsQuery
+	var err error
+
+	if v := ctx.Query("page"); v != "" {
+		if query.Page, err = strconv.Atoi(v); err != nil {
+			return domain.ItemsQuery{}, fmt.Errorf("%w: page must be an integer", domain.ErrInvalidQuery)
+		}
+	}
+	if v := ctx.Query("limit"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil {
+			return domain.ItemsQuery{}, fmt.Errorf("%w: limit must be an integer", domain.ErrInvalidQuery)
+		}
+	}
+
+	query.SortBy = ctx.Query("sort")
+	switch ctx.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		query.SortDesc = true
+	default:
+		return domain.ItemsQuery{}, fmt.Errorf("%w: order must be asc or desc", domain.ErrInvalidQuery)
+	}
+
+	query.Name = ctx.Query("name")
+	query.IncludeDeleted = ctx.Query("include_deleted") == "true"
+
+	if v := ctx.Query("min_price"); v != "" {
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return domain.ItemsQuery{}, fmt.Errorf("%w: min_price must be a number", domain.ErrInvalidQuery)
+		}
+		query.MinPrice = &price
+	}
+	if v := ctx.Query("max_price"); v != "" {
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return domain.ItemsQuery{}, fmt.Errorf("%w: max_price must be a number", domain.ErrInvalidQuery)
+		}
+		query.MaxPrice = &price
+	}
+
+	if v := ctx.Query("after"); v != "" {
+		cursor, err := c.cursors.Decode(v)
+		if err != nil {
+			return domain.ItemsQuery{}, err
+		}
+		query.After = &cursor
+	}
+
+	return query, nil
+}
+
+// SearchItems maneja GET /items/search - Búsqueda por texto con ranking
+// Query params: q (texto a buscar, obligatorio), limit
+// Ej.: GET /items/search?q=dark%20coffee&limit=5
+func (c *ItemsController) SearchItems(ctx *gin.Context) {
+	query := domain.SearchQuery{Text: ctx.Query("q")}
+	if v := ctx.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			respondError(ctx, "Invalid query parameters", fmt.Errorf("%w: limit must be an integer", domain.ErrInvalidQuery))
+			return
+		}
+		query.Limit = limit
+	}
+
+	hits, err := c.service.Search(ctx.Request.Context(), query)
+	if err != nil {
+		respondError(ctx, "Failed to search items", err)
 		return
 	}
 
-	// ✅ Respuesta exitosa con los datos
 	ctx.JSON(http.StatusOK, gin.H{
-		"items": items,
-		"count": len(items),
+		"query":   query.Text,
+		"results": hits,
+		"count":   len(hits),
 	})
 }
 
 // CreateItem maneja POST /items - Crea un nuevo item
 // Consigna 1: Recibir JSON, validar y crear item
 func (c *ItemsController) CreateItem(ctx *gin.Context) {
-	ctx.JSON(http.StatusNotImplemented, gin.H{"error": "TODO: implementar CreateItem"})
+	// 📥 Parsear el body JSON al modelo de dominio
+	var item domain.Item
+	if err := ctx.ShouldBindJSON(&item); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid JSON body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	created, err := c.service.Create(ctx.Request.Context(), item)
+	if err != nil {
+		respondError(ctx, "Failed to create item", err)
+		return
+	}
+
+	// ✅ 201 Created con el item creado (incluye ID y timestamps)
+	setETag(ctx, created)
+	ctx.JSON(http.StatusCreated, created)
 }
 
 // GetItemByID maneja GET /items/:id - Obtiene item por ID
 // Consigna 2: Extraer ID del path param, validar y buscar
 func (c *ItemsController) GetItemByID(ctx *gin.Context) {
-	ctx.JSON(http.StatusNotImplemented, gin.H{"error": "TODO: implementar GetItemByID"})
+	id := ctx.Param("id")
+
+	item, err := c.service.GetByID(ctx.Request.Context(), id)
+	if err != nil {
+		respondError(ctx, "Failed to get item", err)
+		return
+	}
+
+	// 🏷️ ETag = versión actual, el cliente la devuelve en If-Match al modificar
+	setETag(ctx, item)
+	ctx.JSON(http.StatusOK, item)
 }
 
 // UpdateItem maneja PUT /items/:id - Actualiza item existente
 // Consigna 3: Extraer ID y datos, validar y actualizar
+// Con header If-Match solo actualiza si la versión coincide (si no, 412)
 func (c *ItemsController) UpdateItem(ctx *gin.Context) {
-	ctx.JSON(http.StatusNotImplemented, gin.H{"error": "TODO: implementar UpdateItem"})
+	id := ctx.Param("id")
+
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		respondError(ctx, "Invalid If-Match header", err)
+		return
+	}
+
+	var item domain.Item
+	if err := ctx.ShouldBindJSON(&item); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid JSON body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// La versión esperada sale del header, no del body
+	item.Version = version
+
+	updated, err := c.service.Update(ctx.Request.Context(), id, item)
+	if err != nil {
+		respondError(ctx, "Failed to update item", err)
+		return
+	}
+
+	setETag(ctx, updated)
+	ctx.JSON(http.StatusOK, updated)
 }
 
 // DeleteItem maneja DELETE /items/:id - Elimina item por ID
 // Consigna 4: Extraer ID, validar y eliminar
+// Por defecto es un borrado lógico (recuperable con POST /items/:id/restore)
+// DELETE /items/:id?purge=true lo elimina definitivamente (solo admin)
+// Con header If-Match solo elimina si la versión coincide (si no, 412)
 func (c *ItemsController) DeleteItem(ctx *gin.Context) {
-	ctx.JSON(http.StatusNotImplemented, gin.H{"error": "TODO: implementar DeleteItem"})
+	id := ctx.Param("id")
+
+	version, err := ifMatchVersion(ctx)
+	if err != nil {
+		respondError(ctx, "Invalid If-Match header", err)
+		return
+	}
+
+	if ctx.Query("purge") == "true" {
+		// 🔒 El purge no se puede deshacer: solo para administradores
+		if !middleware.IsAdmin(ctx) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Purge requires admin privileges"})
+			return
+		}
+
+		if err := c.service.Purge(ctx.Request.Context(), id, version); err != nil {
+			respondError(ctx, "Failed to purge item", err)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+
+	if err := c.service.Delete(ctx.Request.Context(), id, version); err != nil {
+		respondError(ctx, "Failed to delete item", err)
+		return
+	}
+
+	// ✅ 204 No Content: borrado exitoso, sin body
+	ctx.Status(http.StatusNoContent)
+}
+
+// RestoreItem maneja POST /items/:id/restore - Recupera un item borrado
+func (c *ItemsController) RestoreItem(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	restored, err := c.service.Restore(ctx.Request.Context(), id)
+	if err != nil {
+		respondError(ctx, "Failed to restore item", err)
+		return
+	}
+
+	setETag(ctx, restored)
+	ctx.JSON(http.StatusOK, restored)
+}
+
+// CreateItems maneja POST /items/batch - Crea varios items
+// Body: {"items": [{"name": "...", "price": 1.5}, ...]}
+func (c *ItemsController) CreateItems(ctx *gin.Context) {
+	var body struct {
+		Items []domain.Item `json:"items"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid JSON body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	results, err := c.service.CreateBatch(ctx.Request.Context(), body.Items)
+	if err != nil {
+		respondError(ctx, "Failed to create items", err)
+		return
+	}
+
+	respondBatch(ctx, results)
+}
+
+// UpdateItems maneja PUT /items/batch - Actualiza varios items
+// Body: {"items": [{"id": "...", "name": "...", "price": 1.5, "version": 3}, ...]}
+// version es la versión esperada de cada item (0 u omitida = sin precondición)
+func (c *ItemsController) UpdateItems(ctx *gin.Context) {
+	var body struct {
+		Items []domain.Item `json:"items"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid JSON body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	results, err := c.service.UpdateBatch(ctx.Request.Context(), body.Items)
+	if err != nil {
+		respondError(ctx, "Failed to update items", err)
+		return
+	}
+
+	respondBatch(ctx, results)
+}
+
+// DeleteItems maneja DELETE /items/batch - Borra lógicamente varios items
+// Body: {"items": [{"id": "...", "version": 3}, ...]}
+func (c *ItemsController) DeleteItems(ctx *gin.Context) {
+	var body struct {
+		Items []domain.ItemRef `json:"items"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid JSON body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	results, err := c.service.DeleteBatch(ctx.Request.Context(), body.Items)
+	if err != nil {
+		respondError(ctx, "Failed to delete items", err)
+		return
+	}
+
+	respondBatch(ctx, results)
+}
+
+// respondBatch arma la respuesta de un batch con un resultado por item
+// 200 si todos salieron bien, 207 Multi-Status si alguno falló
+func respondBatch(ctx *gin.Context, results []domain.BatchResult) {
+	response := make([]gin.H, len(results))
+	failed := 0
+	for i, res := range results {
+		entry := gin.H{"index": res.Index, "id": res.ID, "status": http.StatusOK}
+		if res.Err != nil {
+			failed++
+			entry["status"] = errorStatus(res.Err)
+			entry["error"] = res.Err.Error()
+		} else {
+			entry["item"] = res.Item
+		}
+		response[i] = entry
+	}
+
+	status := http.StatusOK
+	if failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	ctx.JSON(status, gin.H{
+		"results":   response,
+		"succeeded": len(results) - failed,
+		"failed":    failed,
+	})
+}
+
+// respondError traduce los errores de dominio a status HTTP
+// 🎯 Helper para manejar respuestas de error de manera consistente
+func respondError(ctx *gin.Context, message string, err error) {
+	ctx.JSON(errorStatus(err), gin.H{
+		"error":   message,
+		"details": err.Error(),
+	})
+}
+
+// errorStatus retorna el status HTTP que corresponde a un error de dominio
+func errorStatus(err error) int {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, domain.ErrInvalidID), errors.Is(err, domain.ErrInvalidItem), errors.Is(err, domain.ErrInvalidQuery):
+		status = http.StatusBadRequest
+	case errors.Is(err, domain.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, domain.ErrAlreadyExists):
+		status = http.StatusConflict
+	case errors.Is(err, domain.ErrVersionConflict):
+		status = http.StatusPreconditionFailed
+	case errors.Is(err, domain.ErrNotSupported):
+		status = http.StatusNotImplemented
+	}
+	return status
+}
+
+// setETag expone la versión del item como ETag (ej.: "3")
+func setETag(ctx *gin.Context, item domain.Item) {
+	ctx.Header("ETag", strconv.Quote(strconv.FormatInt(item.Version, 10)))
+}
+
+// ifMatchVersion lee el header If-Match y devuelve la versión esperada
+// Sin header o con "*" retorna 0 (sin precondición)
+func ifMatchVersion(ctx *gin.Context) (int64, error) {
+	value := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	// Aceptamos "3", W/"3" y 3
+	value = strings.TrimPrefix(value, "W/")
+	value = strings.Trim(value, `"`)
+
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version <= 0 {
+		// Un ETag que no es una versión nuestra nunca puede coincidir
+		// (las versiones empiezan en 1: "0" no es "sin precondición")
+		return 0, fmt.Errorf("%w: unknown ETag %s", domain.ErrVersionConflict, ctx.GetHeader("If-Match"))
+	}
+	return version, nil
 }
 
 // 📚 Notas sobre HTTP Status Codes
@@ -95,10 +507,13 @@ func (c *ItemsController) DeleteItem(ctx *gin.Context) {
 // 200 OK - Operación exitosa con contenido
 // 201 Created - Recurso creado exitosamente
 // 204 No Content - Operación exitosa sin contenido (típico para DELETE)
+// 207 Multi-Status - Batch con resultados mixtos (ver "results")
 // 400 Bad Request - Error en los datos enviados por el cliente
+// 403 Forbidden - El cliente no tiene permisos (ej.: purge sin ser admin)
 // 404 Not Found - Recurso no encontrado
+// 409 Conflict - Ya existe un item con ese nombre
+// 412 Precondition Failed - El If-Match no coincide con la versión actual
 // 500 Internal Server Error - Error interno del servidor
 // 501 Not Implemented - Funcionalidad no implementada (para TODOs)
 //
-// 💡 Tip: En una API real, sería buena práctica crear una función
-// helper para manejar respuestas de error de manera consistente
+// 💡 Tip: respondError centraliza el mapeo error de dominio -> status HTTP
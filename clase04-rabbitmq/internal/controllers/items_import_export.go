@@ -0,0 +1,356 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"clase04-rabbitmq/internal/domain"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	formatCSV   = "csv"
+	formatJSONL = "jsonl"
+
+	// importChunkSize es la cantidad de filas que se mandan juntas al service
+	importChunkSize = 500
+
+	// maxImportBytes limita el tamaño del archivo subido
+	maxImportBytes = 50 << 20 // 50 MB
+
+	// exportFlushEvery es cada cuántos items se envía lo escrito al cliente
+	exportFlushEvery = 500
+)
+
+// csvHeader son las columnas del export CSV
+// El import acepta cualquier orden y solo requiere name y price (id es opcional)
+var csvHeader = []string{"id", "name", "price", "version", "created_at", "updated_at"}
+
+// ExportItems maneja GET /items/export?format=csv|jsonl - Descarga el catálogo
+// Se escribe a medida que se lee de DB: nunca se carga la colección completa
+func (c *ItemsController) ExportItems(ctx *gin.Context) {
+	format := ctx.DefaultQuery("format", formatCSV)
+
+	var write func(domain.Item) error
+	var flush func() error
+
+	switch format {
+	case formatCSV:
+		// csv.Writer tiene buffer: el header no sale hasta el primer flush
+		w := csv.NewWriter(ctx.Writer)
+		_ = w.Write(csvHeader)
+		write = func(item domain.Item) error {
+			return w.Write([]string{
+				item.ID,
+				item.Name,
+				strconv.FormatFloat(item.Price, 'f', -1, 64),
+				strconv.FormatInt(item.Version, 10),
+				item.CreatedAt.Format(time.RFC3339),
+				item.UpdatedAt.Format(time.RFC3339),
+			})
+		}
+		flush = func() error {
+			w.Flush()
+			return w.Error()
+		}
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	case formatJSONL:
+		w := bufio.NewWriter(ctx.Writer)
+		encoder := json.NewEncoder(w) // Encode agrega el \n de cada línea
+		write = func(item domain.Item) error {
+			return encoder.Encode(item)
+		}
+		flush = w.Flush
+		ctx.Header("Content-Type", "application/x-ndjson")
+	default:
+		respondError(ctx, "Invalid query parameters", fmt.Errorf("%w: format must be csv or jsonl", domain.ErrInvalidQuery))
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="items.%s"`, format))
+
+	count := 0
+	err := c.service.Export(ctx.Request.Context(), func(item domain.Item) error {
+		if err := write(item); err != nil {
+			return err
+		}
+		count++
+		// 🚿 Cada tanto empujamos lo escrito al cliente
+		if count%exportFlushEvery == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			ctx.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+
+	if err != nil {
+		// Si todavía no se escribió nada podemos responder un error normal;
+		// si no, el status ya salió y solo queda cortar la descarga
+		if !ctx.Writer.Written() {
+			ctx.Writer.Header().Del("Content-Type")
+			ctx.Writer.Header().Del("Content-Disposition")
+			respondError(ctx, "Failed to export items", err)
+			return
+		}
+		log.Printf("error exporting items after %d rows: %v", count, err)
+		ctx.Abort()
+	}
+}
+
+// importRowError es una fila del import que no se pudo procesar
+type importRowError struct {
+	Row   int    `json:"row"`
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Error string `json:"error"`
+}
+
+// importReport resume el resultado de un import
+type importReport struct {
+	Processed int              `json:"processed"`
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Failed    int              `json:"failed"`
+	Errors    []importRowError `json:"errors"`
+}
+
+// ImportItems maneja POST /items/import - Carga masiva desde CSV o JSON Lines
+// Acepta el archivo como multipart (campo "file") o como body crudo.
+// El formato sale de ?format=, de la extensión del archivo o del Content-Type
+// Cada fila se valida por separado; las filas con id actualizan ese item y
+// las sin id hacen upsert por nombre
+func (c *ItemsController) ImportItems(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBytes)
+
+	source, format, err := importSource(ctx)
+	if err != nil {
+		respondError(ctx, "Invalid import file", err)
+		return
+	}
+	defer source.Close()
+
+	var next func() (int, domain.Item, error)
+	switch format {
+	case formatCSV:
+		next, err = csvRows(source)
+	case formatJSONL:
+		next = jsonlRows(source)
+	}
+	if err != nil {
+		respondError(ctx, "Invalid import file", err)
+		return
+	}
+
+	report := importReport{Errors: []importRowError{}}
+	var chunk []domain.Item
+	var chunkRows []int
+
+	// processChunk manda un lote al service y anota el resultado de cada fila
+	processChunk := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		results, err := c.service.Import(ctx.Request.Context(), chunk)
+		if err != nil {
+			return err
+		}
+		for _, res := range results {
+			if res.Err != nil {
+				item := chunk[res.Index]
+				report.Errors = append(report.Errors, importRowError{
+					Row: chunkRows[res.Index], ID: item.ID, Name: item.Name, Error: res.Err.Error(),
+				})
+				continue
+			}
+			if res.Created {
+				report.Created++
+			} else {
+				report.Updated++
+			}
+		}
+		chunk, chunkRows = chunk[:0], chunkRows[:0]
+		return nil
+	}
+
+	for {
+		row, item, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var rowErr *rowError
+		if errors.As(err, &rowErr) {
+			// Fila mal formada: se reporta y se sigue con la próxima
+			report.Processed++
+			report.Errors = append(report.Errors, importRowError{Row: row, Error: rowErr.Error()})
+			continue
+		}
+		if err != nil {
+			respondError(ctx, "Failed to read import file", fmt.Errorf("%w: %v", domain.ErrInvalidQuery, err))
+			return
+		}
+
+		report.Processed++
+		chunk = append(chunk, item)
+		chunkRows = append(chunkRows, row)
+		if len(chunk) == importChunkSize {
+			if err := processChunk(); err != nil {
+				respondError(ctx, "Failed to import items", err)
+				return
+			}
+		}
+	}
+	if err := processChunk(); err != nil {
+		respondError(ctx, "Failed to import items", err)
+		return
+	}
+
+	report.Failed = len(report.Errors)
+	ctx.JSON(http.StatusOK, report)
+}
+
+// importSource obtiene el archivo a importar y su formato
+func importSource(ctx *gin.Context) (io.ReadCloser, string, error) {
+	format := ctx.Query("format")
+
+	var source io.ReadCloser = ctx.Request.Body
+	contentType := ctx.ContentType()
+	if strings.HasPrefix(contentType, "multipart/") {
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: multipart upload requires a \"file\" field", domain.ErrInvalidQuery)
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", fmt.Errorf("error opening uploaded file: %w", err)
+		}
+		source = file
+
+		if format == "" {
+			switch strings.ToLower(filepath.Ext(header.Filename)) {
+			case ".csv":
+				format = formatCSV
+			case ".jsonl", ".ndjson":
+				format = formatJSONL
+			}
+		}
+		contentType = header.Header.Get("Content-Type")
+	}
+
+	if format == "" {
+		switch {
+		case strings.Contains(contentType, "csv"):
+			format = formatCSV
+		case strings.Contains(contentType, "ndjson"), strings.Contains(contentType, "jsonl"):
+			format = formatJSONL
+		}
+	}
+
+	if format != formatCSV && format != formatJSONL {
+		source.Close()
+		return nil, "", fmt.Errorf("%w: format must be csv or jsonl", domain.ErrInvalidQuery)
+	}
+	return source, format, nil
+}
+
+// rowError es un error de formato en una fila puntual (no corta el import)
+type rowError struct {
+	msg string
+}
+
+func (e *rowError) Error() string { return e.msg }
+
+// csvRows lee el header y retorna un iterador de filas
+// El número de fila cuenta el header como fila 1
+func csvRows(source io.Reader) (func() (int, domain.Item, error), error) {
+	reader := csv.NewReader(source)
+	reader.FieldsPerRecord = -1 // La cantidad de columnas se valida por fila
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing CSV header", domain.ErrInvalidQuery)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "price"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: CSV header must include %q", domain.ErrInvalidQuery, required)
+		}
+	}
+
+	row := 1
+	return func() (int, domain.Item, error) {
+		record, err := reader.Read()
+		row++
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return row, domain.Item{}, &rowError{msg: parseErr.Err.Error()}
+		}
+		if err != nil {
+			return row, domain.Item{}, err
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		price, err := strconv.ParseFloat(field("price"), 64)
+		if err != nil {
+			return row, domain.Item{}, &rowError{msg: fmt.Sprintf("invalid price %q", field("price"))}
+		}
+
+		return row, domain.Item{ID: field("id"), Name: field("name"), Price: price}, nil
+	}, nil
+}
+
+// jsonlRows retorna un iterador sobre las líneas de un archivo JSON Lines
+// Las líneas vacías se ignoran pero cuentan para el número de fila
+func jsonlRows(source io.Reader) func() (int, domain.Item, error) {
+	scanner := bufio.NewScanner(source)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // Hasta 1 MB por línea
+
+	row := 0
+	return func() (int, domain.Item, error) {
+		for scanner.Scan() {
+			row++
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+
+			var item domain.Item
+			if err := json.Unmarshal(line, &item); err != nil {
+				return row, domain.Item{}, &rowError{msg: fmt.Sprintf("invalid JSON: %v", err)}
+			}
+			return row, domain.Item{ID: item.ID, Name: item.Name, Price: item.Price}, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return row, domain.Item{}, err
+		}
+		return row, domain.Item{}, io.EOF
+	}
+}
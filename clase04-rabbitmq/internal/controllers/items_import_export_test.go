@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parsedRow es una fila leída por un iterador del import
+type parsedRow struct {
+	Row    int
+	Item   domain.Item
+	RowErr bool // Error de formato de la fila (rowError)
+}
+
+// readRows recorre el iterador hasta io.EOF
+func readRows(t *testing.T, next func() (int, domain.Item, error)) []parsedRow {
+	t.Helper()
+	var rows []parsedRow
+	for {
+		row, item, err := next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		var rowErr *rowError
+		if err != nil && !errors.As(err, &rowErr) {
+			t.Fatalf("unexpected error at row %d: %v", row, err)
+		}
+		rows = append(rows, parsedRow{Row: row, Item: item, RowErr: err != nil})
+	}
+}
+
+func TestCSVRows(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantErr  bool // Error del header (corta el import)
+		wantRows []parsedRow
+	}{
+		{
+			name:  "header y filas",
+			input: "name,price\nCafé,1.5\nTé,2\n",
+			wantRows: []parsedRow{
+				{Row: 2, Item: domain.Item{Name: "Café", Price: 1.5}},
+				{Row: 3, Item: domain.Item{Name: "Té", Price: 2}},
+			},
+		},
+		{
+			name:  "columnas en otro orden, con id y espacios",
+			input: " ID , Price ,Name\nabc, 3 , Mate \n",
+			wantRows: []parsedRow{
+				{Row: 2, Item: domain.Item{ID: "abc", Name: "Mate", Price: 3}},
+			},
+		},
+		{
+			name:  "precio inválido no corta el resto",
+			input: "name,price\nCafé,gratis\nTé,2\n",
+			wantRows: []parsedRow{
+				{Row: 2, RowErr: true},
+				{Row: 3, Item: domain.Item{Name: "Té", Price: 2}},
+			},
+		},
+		{
+			name:  "fila con columnas faltantes",
+			input: "name,price\nCafé\n",
+			wantRows: []parsedRow{
+				{Row: 2, RowErr: true},
+			},
+		},
+		{
+			name:  "comillas mal cerradas",
+			input: "name,price\n\"Café,1\n",
+			wantRows: []parsedRow{
+				{Row: 2, RowErr: true},
+			},
+		},
+		{
+			name:    "sin header",
+			input:   "",
+			wantErr: true,
+		},
+		{
+			name:    "header sin price",
+			input:   "name\nCafé\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := csvRows(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrInvalidQuery) {
+					t.Fatalf("csvRows() error = %v, want ErrInvalidQuery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("csvRows() error = %v", err)
+			}
+			if got := readRows(t, next); !reflect.DeepEqual(got, tt.wantRows) {
+				t.Errorf("csvRows() rows = %+v, want %+v", got, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestJSONLRows(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantRows []parsedRow
+	}{
+		{
+			name:  "una fila por línea",
+			input: "{\"name\":\"Café\",\"price\":1.5}\n{\"id\":\"abc\",\"name\":\"Té\",\"price\":2}\n",
+			wantRows: []parsedRow{
+				{Row: 1, Item: domain.Item{Name: "Café", Price: 1.5}},
+				{Row: 2, Item: domain.Item{ID: "abc", Name: "Té", Price: 2}},
+			},
+		},
+		{
+			name:  "líneas vacías cuentan para el número de fila",
+			input: "\n  \n{\"name\":\"Café\",\"price\":1}",
+			wantRows: []parsedRow{
+				{Row: 3, Item: domain.Item{Name: "Café", Price: 1}},
+			},
+		},
+		{
+			name:  "JSON inválido no corta el resto",
+			input: "{\"name\":\n{\"name\":\"Té\",\"price\":2}\n",
+			wantRows: []parsedRow{
+				{Row: 1, RowErr: true},
+				{Row: 2, Item: domain.Item{Name: "Té", Price: 2}},
+			},
+		},
+		{
+			name:  "sólo se toman id, name y price",
+			input: "{\"name\":\"Café\",\"price\":1,\"version\":7}\n",
+			wantRows: []parsedRow{
+				{Row: 1, Item: domain.Item{Name: "Café", Price: 1}},
+			},
+		},
+		{
+			name:  "vacío",
+			input: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readRows(t, jsonlRows(strings.NewReader(tt.input))); !reflect.DeepEqual(got, tt.wantRows) {
+				t.Errorf("jsonlRows() rows = %+v, want %+v", got, tt.wantRows)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeItemsService registra las versiones esperadas que recibe en Update y Delete
+// Los métodos que no se usan en los tests entran en pánico (interfaz embebida nil)
+type fakeItemsService struct {
+	ItemsService
+	versions []int64
+}
+
+func (s *fakeItemsService) Update(ctx context.Context, id string, item domain.Item) (domain.Item, error) {
+	s.versions = append(s.versions, item.Version)
+	item.ID = id
+	item.Version++
+	return item, nil
+}
+
+func (s *fakeItemsService) Delete(ctx context.Context, id string, version int64) error {
+	s.versions = append(s.versions, version)
+	return nil
+}
+
+func TestIfMatchPrecondition(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		method      string
+		ifMatch     string
+		wantStatus  int
+		wantVersion int64 // Versión que llega al service (si llega)
+	}{
+		{name: "PUT sin header", method: http.MethodPut, wantStatus: http.StatusOK, wantVersion: 0},
+		{name: "PUT con *", method: http.MethodPut, ifMatch: "*", wantStatus: http.StatusOK, wantVersion: 0},
+		{name: "PUT con versión", method: http.MethodPut, ifMatch: `"3"`, wantStatus: http.StatusOK, wantVersion: 3},
+		{name: "PUT con ETag débil", method: http.MethodPut, ifMatch: `W/"3"`, wantStatus: http.StatusOK, wantVersion: 3},
+		{name: "PUT con versión 0", method: http.MethodPut, ifMatch: `"0"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "PUT con ETag débil 0", method: http.MethodPut, ifMatch: `W/"0"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "PUT con versión negativa", method: http.MethodPut, ifMatch: `"-1"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "PUT con ETag ajeno", method: http.MethodPut, ifMatch: `"abc"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "DELETE con versión 0", method: http.MethodDelete, ifMatch: `"0"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "DELETE con versión", method: http.MethodDelete, ifMatch: `"2"`, wantStatus: http.StatusNoContent, wantVersion: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeItemsService{}
+			controller := NewItemsController(service, nil)
+
+			router := gin.New()
+			router.PUT("/items/:id", controller.UpdateItem)
+			router.DELETE("/items/:id", controller.DeleteItem)
+
+			req := httptest.NewRequest(tt.method, "/items/665f1c2e9b1d4a0001a1b2c3", strings.NewReader(`{"name":"Café","price":1}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusPreconditionFailed {
+				if len(service.versions) != 0 {
+					t.Errorf("service was called with versions %v, want no call", service.versions)
+				}
+				return
+			}
+			if len(service.versions) != 1 || service.versions[0] != tt.wantVersion {
+				t.Errorf("service versions = %v, want [%d]", service.versions, tt.wantVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package correlation
+
+import "context"
+
+// Header es el header HTTP con el que se propaga el correlation ID
+const Header = "X-Correlation-ID"
+
+type contextKey struct{}
+
+// WithID guarda el correlation ID en el context
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// ID retorna el correlation ID del context ("" si no tiene)
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
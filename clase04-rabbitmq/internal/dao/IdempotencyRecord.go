@@ -0,0 +1,30 @@
+package dao
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"time"
+)
+
+type IdempotencyRecord struct {
+	Key         string            `bson:"_id"`
+	RequestHash string            `bson:"request_hash"`
+	Completed   bool              `bson:"completed"`
+	LockedUntil time.Time         `bson:"locked_until"` // Lease del request en proceso
+	StatusCode  int               `bson:"status_code,omitempty"`
+	Headers     map[string]string `bson:"headers,omitempty"`
+	Body        []byte            `bson:"body,omitempty"`
+	CreatedAt   time.Time         `bson:"created_at"`
+}
+
+// ToDomain convierte de modelo DB a modelo de negocio
+func (d IdempotencyRecord) ToDomain() domain.IdempotencyRecord {
+	return domain.IdempotencyRecord{
+		Key:         d.Key,
+		RequestHash: d.RequestHash,
+		Completed:   d.Completed,
+		StatusCode:  d.StatusCode,
+		Headers:     d.Headers,
+		Body:        d.Body,
+		CreatedAt:   d.CreatedAt,
+	}
+}
@@ -11,8 +11,10 @@ type Item struct {
 	ID        primitive.ObjectID `bson:"_id"`
 	Name      string             `bson:"name"`
 	Price     float64            `bson:"price"`
+	Version   int64              `bson:"version"`
 	CreatedAt time.Time          `bson:"created_at"`
 	UpdatedAt time.Time          `bson:"updated_at"`
+	DeletedAt *time.Time         `bson:"deleted_at,omitempty"`
 }
 
 // ToDomain convierte de modelo DB a modelo de negocio
@@ -21,8 +23,10 @@ func (d Item) ToDomain() domain.Item {
 		ID:        d.ID.Hex(), // ObjectID -> string
 		Name:      d.Name,
 		Price:     d.Price,
+		Version:   d.Version,
 		CreatedAt: d.CreatedAt,
 		UpdatedAt: d.UpdatedAt,
+		DeletedAt: d.DeletedAt,
 	}
 }
 
@@ -38,7 +42,9 @@ func FromDomain(domainItem domain.Item) Item {
 		ID:        objectID,
 		Name:      domainItem.Name,
 		Price:     domainItem.Price,
+		Version:   domainItem.Version,
 		CreatedAt: domainItem.CreatedAt,
 		UpdatedAt: domainItem.UpdatedAt,
+		DeletedAt: domainItem.DeletedAt,
 	}
 }
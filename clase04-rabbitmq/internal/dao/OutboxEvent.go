@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OutboxEvent struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	Type          string             `bson:"type"`    // Type del CloudEvent (para consultar desde el shell)
+	ItemID        string             `bson:"item_id"` // Subject del CloudEvent
+	Event         string             `bson:"event"`   // CloudEvent serializado en JSON, tal cual se publica
+	CreatedAt     time.Time          `bson:"created_at"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at"` // No se publica antes de esta fecha (backoff / lease)
+	Attempts      int                `bson:"attempts"`
+	LastError     string             `bson:"last_error,omitempty"`
+	SentAt        *time.Time         `bson:"sent_at,omitempty"` // nil = pendiente
+}
+
+// ToDomain convierte de modelo DB a modelo de negocio
+func (d OutboxEvent) ToDomain() (domain.OutboxEvent, error) {
+	var event domain.CloudEvent
+	if err := json.Unmarshal([]byte(d.Event), &event); err != nil {
+		return domain.OutboxEvent{}, fmt.Errorf("error decoding outbox event %s: %w", d.ID.Hex(), err)
+	}
+	return domain.OutboxEvent{
+		ID:        d.ID.Hex(),
+		Event:     event,
+		CreatedAt: d.CreatedAt,
+		Attempts:  d.Attempts,
+	}, nil
+}
+
+// OutboxEventFromDomain convierte un CloudEvent en una fila pendiente del outbox
+func OutboxEventFromDomain(event domain.CloudEvent, now time.Time) (OutboxEvent, error) {
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		return OutboxEvent{}, fmt.Errorf("error encoding outbox event %s: %w", event.ID, err)
+	}
+	return OutboxEvent{
+		ID:            primitive.NewObjectID(),
+		Type:          event.Type,
+		ItemID:        event.Subject,
+		Event:         string(bytes),
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}, nil
+}
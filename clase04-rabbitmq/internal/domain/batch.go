@@ -0,0 +1,26 @@
+package domain
+
+// MaxBatchSize es la cantidad máxima de items por request batch
+const MaxBatchSize = 1000
+
+// ItemRef identifica un item y, opcionalmente, la versión esperada
+// (0 = sin precondición), como en Delete
+type ItemRef struct {
+	ID      string `json:"id"`
+	Version int64  `json:"version"`
+}
+
+// BatchResult es el resultado de una operación batch para un item
+// Index es la posición del item en el request original
+type BatchResult struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Item  *Item  `json:"item,omitempty"`
+	Err   error  `json:"-"` // nil = operación exitosa
+
+	// Created indica que la operación insertó el item (ej.: upsert de un import)
+	Created bool `json:"created"`
+
+	// Before es el item antes de la escritura (para los eventos), nil si se creó
+	Before *Item `json:"-"`
+}
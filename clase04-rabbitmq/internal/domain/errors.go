@@ -0,0 +1,44 @@
+package domain
+
+import "errors"
+
+// Errores de dominio compartidos entre capas
+// El repository los retorna, el service los propaga (con %w) y el
+// controller los traduce al status HTTP correspondiente usando errors.Is
+var (
+	// ErrNotFound indica que el item no existe
+	ErrNotFound = errors.New("item not found")
+
+	// ErrInvalidID indica que el ID recibido no tiene un formato válido
+	ErrInvalidID = errors.New("invalid item id")
+
+	// ErrInvalidItem indica que el item no cumple las reglas de negocio
+	ErrInvalidItem = errors.New("invalid item")
+
+	// ErrAlreadyExists indica que ya existe un item con el mismo nombre
+	ErrAlreadyExists = errors.New("item already exists")
+
+	// ErrVersionConflict indica que el item cambió desde que el cliente lo leyó
+	ErrVersionConflict = errors.New("item version conflict")
+
+	// ErrNotSupported indica que el repository no implementa la operación
+	// (ej.: las caches no pueden listar ni buscar)
+	ErrNotSupported = errors.New("operation not supported")
+
+	// ErrInvalidQuery indica que los parámetros de un listado no son válidos
+	ErrInvalidQuery = errors.New("invalid query")
+
+	// ErrCacheMiss indica que la clave no está en cache (no es un error de la cache)
+	ErrCacheMiss = errors.New("item not found in cache")
+
+	// ErrCacheStale acompaña a un item leído de cache que ya venció pero todavía
+	// se puede servir mientras se refresca (stale-while-revalidate)
+	ErrCacheStale = errors.New("stale item in cache")
+
+	// ErrNotDeleted indica que el item a restaurar no estaba borrado
+	ErrNotDeleted = errors.New("item is not deleted")
+
+	// ErrBatchAborted marca los items de un batch que no se escribieron porque
+	// el error de otro item abortó la transacción (se pueden reintentar)
+	ErrBatchAborted = errors.New("batch transaction aborted")
+)
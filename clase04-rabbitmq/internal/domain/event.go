@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// CloudEventsSpecVersion es la versión de la especificación CloudEvents usada
+	CloudEventsSpecVersion = "1.0"
+
+	// ItemEventsSource identifica al productor de los eventos de items
+	ItemEventsSource = "/items-api"
+
+	// ItemEventsSchemaVersion es la versión de los payloads de items
+	// Un cambio incompatible en un payload sube la versión (y el type del evento)
+	ItemEventsSchemaVersion = 1
+
+	// itemEventTypePrefix antecede a la routing key en el type (ej.: ucc.items.created.v1)
+	itemEventTypePrefix = "ucc."
+)
+
+// CloudEvent es el sobre CloudEvents 1.0 (formato JSON) de los eventos de items
+// https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/formats/json-format.md
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"` // También es el MessageId de AMQP
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`    // ucc.items.<evento>.v<versión>
+	Subject         string          `json:"subject"` // ID del item
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	CorrelationID   string          `json:"correlationid,omitempty"` // Extensión: request que originó el evento
+	Data            json.RawMessage `json:"data"`
+}
+
+// ItemEventData es el payload tipado de un evento de items
+type ItemEventData interface {
+	// Action es la operación que generó el evento ("create", "update"...)
+	Action() string
+	// ItemID es el item afectado (subject del evento)
+	ItemID() string
+}
+
+// ItemCreated se publica al crear un item
+type ItemCreated struct {
+	Item Item `json:"item"`
+}
+
+// ItemUpdated se publica al modificar un item
+// Before es nil si no se conoce el estado previo (ej.: batch con una escritura concurrente)
+type ItemUpdated struct {
+	Before *Item `json:"before,omitempty"`
+	After  Item  `json:"after"`
+}
+
+// ItemDeleted se publica al borrar lógicamente un item
+type ItemDeleted struct {
+	Item Item `json:"item"` // Último estado conocido del item
+}
+
+// ItemRestored se publica al recuperar un item borrado lógicamente
+type ItemRestored struct {
+	Item Item `json:"item"`
+}
+
+// ItemPurged se publica al borrar un item definitivamente
+type ItemPurged struct {
+	ID string `json:"id"`
+}
+
+func (e ItemCreated) Action() string  { return "create" }
+func (e ItemCreated) ItemID() string  { return e.Item.ID }
+func (e ItemUpdated) Action() string  { return "update" }
+func (e ItemUpdated) ItemID() string  { return e.After.ID }
+func (e ItemDeleted) Action() string  { return "delete" }
+func (e ItemDeleted) ItemID() string  { return e.Item.ID }
+func (e ItemRestored) Action() string { return "restore" }
+func (e ItemRestored) ItemID() string { return e.Item.ID }
+func (e ItemPurged) Action() string   { return "purge" }
+func (e ItemPurged) ItemID() string   { return e.ID }
+
+// NewItemEvent arma el CloudEvent de un payload de items
+func NewItemEvent(id string, correlationID string, data ItemEventData, now time.Time) (CloudEvent, error) {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return CloudEvent{}, fmt.Errorf("error marshalling %s event data: %w", data.Action(), err)
+	}
+	return CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              id,
+		Source:          ItemEventsSource,
+		Type:            ItemEventType(data.Action()),
+		Subject:         data.ItemID(),
+		Time:            now,
+		DataContentType: "application/json",
+		CorrelationID:   correlationID,
+		Data:            bytes,
+	}, nil
+}
+
+// DecodeData decodifica el payload en v (ej.: *ItemUpdated según el type)
+func (e CloudEvent) DecodeData(v any) error {
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("error decoding %s event data: %w", e.Type, err)
+	}
+	return nil
+}
+
+// RoutingKey retorna la routing key del evento (ej.: ucc.items.created.v1 -> items.created)
+func (e CloudEvent) RoutingKey() string {
+	key := strings.TrimPrefix(e.Type, itemEventTypePrefix)
+	if i := strings.LastIndex(key, ".v"); i >= 0 {
+		key = key[:i]
+	}
+	return key
+}
+
+// ItemEventType retorna el type CloudEvents de una acción (ej.: "create" -> ucc.items.created.v1)
+func ItemEventType(action string) string {
+	return fmt.Sprintf("%s%s.v%d", itemEventTypePrefix, ItemRoutingKey(action), ItemEventsSchemaVersion)
+}
+
+// itemRoutingKeys traduce cada acción a su routing key en el exchange topic
+var itemRoutingKeys = map[string]string{
+	"create":  "items.created",
+	"update":  "items.updated",
+	"delete":  "items.deleted",
+	"restore": "items.restored",
+	"purge":   "items.purged",
+}
+
+// ItemRoutingKey retorna la routing key de una acción (ej.: "create" -> "items.created")
+func ItemRoutingKey(action string) string {
+	if key, ok := itemRoutingKeys[action]; ok {
+		return key
+	}
+	return "items." + action
+}
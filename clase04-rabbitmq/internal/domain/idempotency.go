@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord es la respuesta guardada para un header Idempotency-Key
+// Mientras el request original se procesa Completed es false
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string // Hash de método + ruta + body del request original
+	Completed   bool
+	StatusCode  int
+	Headers     map[string]string // Headers de la respuesta a repetir (Content-Type, ETag...)
+	Body        []byte
+	CreatedAt   time.Time
+}
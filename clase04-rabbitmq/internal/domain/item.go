@@ -8,6 +8,15 @@ type Item struct {
 	ID        string    `json:"id"`
 	Name      string    `json:"name"`
 	Price     float64   `json:"price"`
+	Version   int64     `json:"version"` // Se incrementa en cada Update (control de concurrencia optimista)
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// DeletedAt marca un borrado lógico (soft delete), nil = item activo
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// IsDeleted indica si el item fue borrado lógicamente
+func (i Item) IsDeleted() bool {
+	return i.DeletedAt != nil
 }
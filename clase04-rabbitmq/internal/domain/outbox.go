@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// OutboxEvent es un evento de items pendiente de publicar en RabbitMQ
+// Se guarda en la misma transacción que la escritura del item, y el relay
+// lo publica después (entrega at-least-once)
+type OutboxEvent struct {
+	ID        string
+	Event     CloudEvent
+	CreatedAt time.Time
+	Attempts  int // Intentos de publicación fallidos
+}
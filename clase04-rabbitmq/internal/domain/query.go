@@ -0,0 +1,49 @@
+package domain
+
+import "time"
+
+// Valores por defecto y límites de paginación para GET /items
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ItemsQuery representa los filtros, orden y paginación de un listado
+// El controller lo arma desde los query params y viaja hasta el repository
+type ItemsQuery struct {
+	Page     int      // Página solicitada (empieza en 1)
+	Limit    int      // Cantidad de items por página
+	SortBy   string   // Campo de orden: name, price, created_at, updated_at
+	SortDesc bool     // true = descendente
+	Name     string   // Substring a buscar en el nombre (case-insensitive)
+	MinPrice *float64 // Precio mínimo (inclusive), nil = sin límite
+	MaxPrice *float64 // Precio máximo (inclusive), nil = sin límite
+
+	IncludeDeleted bool // true = incluye items borrados lógicamente
+
+	// After activa la paginación por cursor (keyset): trae los items
+	// posteriores a esta posición en orden (created_at, _id) ascendente
+	After *ItemsCursor
+}
+
+// ItemsCursor es una posición en el orden (created_at, _id)
+// A diferencia del offset, no se corre si se insertan items concurrentemente
+type ItemsCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// ItemsPage es el resultado paginado de un listado
+type ItemsPage struct {
+	Items []Item `json:"items"`
+	Total int64  `json:"total"` // Total de items que cumplen el filtro (sin paginar)
+	Page  int    `json:"page"`
+	Limit int    `json:"limit"`
+
+	// HasMore indica si quedan items después de esta página
+	HasMore bool `json:"-"`
+
+	// NextCursor es la posición del último item de la página
+	// Solo se completa cuando el orden es (created_at, _id) ascendente
+	NextCursor *ItemsCursor `json:"-"`
+}
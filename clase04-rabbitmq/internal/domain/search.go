@@ -0,0 +1,17 @@
+package domain
+
+// SearchQuery representa una búsqueda de texto libre sobre los items
+type SearchQuery struct {
+	Text  string // Términos a buscar (ej.: "dark coffee")
+	Limit int    // Cantidad máxima de resultados
+}
+
+// SearchHit es un item encontrado junto con su relevancia
+type SearchHit struct {
+	Item  Item    `json:"item"`
+	Score float64 `json:"score"` // Mayor score = más relevante
+
+	// Highlights tiene, por campo, el texto con los términos encontrados
+	// marcados con <em></em> (ej.: {"name": "Dark <em>Coffee</em>"})
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+)
+
+const adminContextKey = "is_admin"
+
+// AdminMiddleware marca el request como administrador si trae el header
+// X-Admin-Token con el token configurado. No corta el request: cada handler
+// decide qué operaciones requieren admin usando IsAdmin
+// Con token vacío ningún request es admin
+func AdminMiddleware(token string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		header := ctx.GetHeader("X-Admin-Token")
+		if token != "" && subtle.ConstantTimeCompare([]byte(header), []byte(token)) == 1 {
+			ctx.Set(adminContextKey, true)
+		}
+		ctx.Next()
+	}
+}
+
+// IsAdmin indica si AdminMiddleware autenticó el request como administrador
+func IsAdmin(ctx *gin.Context) bool {
+	return ctx.GetBool(adminContextKey)
+}
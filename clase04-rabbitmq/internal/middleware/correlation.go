@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"clase04-rabbitmq/internal/correlation"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CorrelationMiddleware asigna un correlation ID a cada request
+// Se respeta el que manda el cliente (X-Correlation-ID) o se genera uno nuevo;
+// se devuelve en la respuesta y viaja en los eventos que genere el request
+func CorrelationMiddleware(ctx *gin.Context) {
+	id := ctx.GetHeader(correlation.Header)
+	if id == "" || len(id) > 128 {
+		id = uuid.New().String()
+	}
+
+	ctx.Request = ctx.Request.WithContext(correlation.WithID(ctx.Request.Context(), id))
+	ctx.Header(correlation.Header, id)
+
+	ctx.Next()
+}
@@ -8,7 +8,8 @@ import (
 func CORSMiddleware(ctx *gin.Context) {
 	ctx.Header("Access-Control-Allow-Origin", "*")
 	ctx.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	ctx.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	ctx.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, X-Admin-Token, X-Correlation-ID, Idempotency-Key")
+	ctx.Header("Access-Control-Expose-Headers", "ETag, X-Correlation-ID, Idempotent-Replayed")
 
 	if ctx.Request.Method == http.MethodOptions {
 		ctx.Status(http.StatusNoContent)
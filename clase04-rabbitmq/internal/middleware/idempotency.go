@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"clase04-rabbitmq/internal/domain"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyHeader es el header con la clave elegida por el cliente
+	IdempotencyHeader = "Idempotency-Key"
+
+	// idempotencyReplayedHeader marca las respuestas repetidas desde el registro
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+
+	// idempotencyLease es cuánto se reserva una clave mientras se procesa el request
+	idempotencyLease = time.Minute
+
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders son los headers de la respuesta original que se repiten
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// IdempotencyStore guarda las respuestas por clave (ver repository.MongoIdempotencyRepository)
+type IdempotencyStore interface {
+	Begin(ctx context.Context, key string, requestHash string, lease time.Duration) (domain.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, record domain.IdempotencyRecord) error
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyMiddleware hace seguros los reintentos de un endpoint
+// Con el header Idempotency-Key:
+//   - la primera vez procesa el request y guarda la respuesta
+//   - los reintentos con el mismo body reciben la respuesta guardada
+//   - reusar la clave con otro body responde 422
+//   - un reintento mientras el original sigue en proceso responde 409
+//
+// Las respuestas 5xx no se guardan: el cliente puede reintentar con la misma clave
+// Sin el header el request se procesa normalmente
+func IdempotencyMiddleware(store IdempotencyStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(IdempotencyHeader)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortIdempotency(ctx, http.StatusBadRequest, "invalid idempotency key", "Idempotency-Key cannot exceed 255 characters")
+			return
+		}
+
+		// 📥 Leemos el body para el hash y lo dejamos disponible para el handler
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			abortIdempotency(ctx, http.StatusBadRequest, "error reading request body", err.Error())
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash := requestHash(ctx.Request.Method, ctx.FullPath(), body)
+
+		record, acquired, err := store.Begin(ctx.Request.Context(), key, hash, idempotencyLease)
+		if err != nil {
+			abortIdempotency(ctx, http.StatusInternalServerError, "error checking idempotency key", err.Error())
+			return
+		}
+		if !acquired {
+			replayIdempotent(ctx, record, hash)
+			return
+		}
+
+		// 🎥 Grabamos la respuesta mientras el handler la escribe
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+		ctx.Next()
+
+		// El cliente pudo haber cortado: guardamos igual para su reintento
+		storeCtx := context.WithoutCancel(ctx.Request.Context())
+		if recorder.Status() >= http.StatusInternalServerError {
+			if err := store.Release(storeCtx, key); err != nil {
+				log.Printf("⚠️ %v", err)
+			}
+			return
+		}
+
+		record.Completed = true
+		record.StatusCode = recorder.Status()
+		record.Body = recorder.body.Bytes()
+		record.Headers = make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				record.Headers[name] = value
+			}
+		}
+		if err := store.Complete(storeCtx, record); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}
+}
+
+// replayIdempotent responde a un reintento según el estado de la clave
+func replayIdempotent(ctx *gin.Context, record domain.IdempotencyRecord, hash string) {
+	switch {
+	case record.RequestHash != hash:
+		abortIdempotency(ctx, http.StatusUnprocessableEntity, "idempotency key reused",
+			"Idempotency-Key was already used with a different request")
+	case !record.Completed:
+		abortIdempotency(ctx, http.StatusConflict, "request in progress",
+			"a request with this Idempotency-Key is still being processed")
+	default:
+		for name, value := range record.Headers {
+			ctx.Header(name, value)
+		}
+		ctx.Header(idempotencyReplayedHeader, "true")
+		ctx.Data(record.StatusCode, record.Headers["Content-Type"], record.Body)
+		ctx.Abort()
+	}
+}
+
+// requestHash identifica un request por método, ruta y body
+func requestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func abortIdempotency(ctx *gin.Context, status int, message string, details string) {
+	ctx.AbortWithStatusJSON(status, gin.H{
+		"error":   message,
+		"details": details,
+	})
+}
+
+// responseRecorder copia el body de la respuesta mientras se escribe
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
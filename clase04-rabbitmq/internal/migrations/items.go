@@ -0,0 +1,184 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Items retorna las migraciones de la colección de items
+// ⚠️ Nunca modificar una migración ya publicada: agregar una nueva versión
+func Items(collection string) []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "rename createdAt to created_at and backfill timestamps and version",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				return backfillItems(ctx, db.Collection(collection))
+			},
+		},
+		{
+			Version:     2,
+			Description: "create items indexes (unique name, price, created_at)",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				return createItemsIndexes(ctx, db.Collection(collection))
+			},
+		},
+		{
+			Version:     3,
+			Description: "apply items JSON schema validator",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				return applyItemsValidator(ctx, db, collection)
+			},
+		},
+		{
+			Version:     4,
+			Description: "make the unique name index ignore soft-deleted items",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				return uniqueActiveNames(ctx, db.Collection(collection))
+			},
+		},
+	}
+}
+
+// backfillItems normaliza los documentos viejos al formato de dao.Item
+// El seed original escribía createdAt (camelCase) y no tenía updated_at ni version
+func backfillItems(ctx context.Context, col *mongo.Collection) error {
+	steps := []struct {
+		name   string
+		filter bson.M
+		update mongo.Pipeline
+	}{
+		{
+			// createdAt -> created_at (si ambos existen gana created_at)
+			name:   "rename createdAt",
+			filter: bson.M{"createdAt": bson.M{"$exists": true}},
+			update: mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{"created_at": bson.M{"$ifNull": bson.A{"$created_at", "$createdAt"}}}}},
+				{{Key: "$unset", Value: "createdAt"}},
+			},
+		},
+		{
+			// Sin fecha de creación: usamos el timestamp embebido en el ObjectID
+			name:   "backfill created_at",
+			filter: bson.M{"created_at": bson.M{"$exists": false}},
+			update: mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{"created_at": bson.M{"$toDate": "$_id"}}}},
+			},
+		},
+		{
+			name:   "backfill updated_at",
+			filter: bson.M{"updated_at": bson.M{"$exists": false}},
+			update: mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{"updated_at": "$created_at"}}},
+			},
+		},
+		{
+			name:   "backfill version",
+			filter: bson.M{"version": bson.M{"$exists": false}},
+			update: mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{"version": int64(1)}}},
+			},
+		},
+	}
+
+	for _, step := range steps {
+		if _, err := col.UpdateMany(ctx, step.filter, step.update); err != nil {
+			return fmt.Errorf("error running %s: %w", step.name, err)
+		}
+	}
+	return nil
+}
+
+// createItemsIndexes crea los índices usados por los listados y búsquedas
+func createItemsIndexes(ctx context.Context, col *mongo.Collection) error {
+	_, err := col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// Nombre único: falla si ya hay duplicados (hay que resolverlos a mano)
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetName("name_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "price", Value: 1}},
+			Options: options.Index().SetName("price"),
+		},
+		{
+			// (created_at, _id) cubre el orden de la paginación por cursor
+			Keys:    bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("created_at_id"),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating indexes: %w", err)
+	}
+	return nil
+}
+
+// uniqueActiveNames reemplaza name_unique por un índice único sobre (name, deleted_at)
+// Los items activos no tienen deleted_at (se indexa como null) y compiten por el
+// nombre; los borrados lógicamente tienen su fecha de borrado y no lo reservan
+// ⚠️ Un índice parcial con {deleted_at: {$exists: false}} sería lo natural, pero
+// Mongo no acepta $exists: false en partialFilterExpression
+func uniqueActiveNames(ctx context.Context, col *mongo.Collection) error {
+	// Primero el índice nuevo: en ningún momento queda el nombre sin índice único
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}, {Key: "deleted_at", Value: 1}},
+		Options: options.Index().SetName("name_active_unique").SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating name_active_unique index: %w", err)
+	}
+
+	if _, err := col.Indexes().DropOne(ctx, "name_unique"); err != nil {
+		return fmt.Errorf("error dropping name_unique index: %w", err)
+	}
+	return nil
+}
+
+// itemsSchema es el $jsonSchema que valida las escrituras de items
+var itemsSchema = bson.M{
+	"bsonType": "object",
+	"required": bson.A{"name", "price", "created_at", "updated_at"},
+	"properties": bson.M{
+		"name":       bson.M{"bsonType": "string", "minLength": 1},
+		"price":      bson.M{"bsonType": bson.A{"double", "int", "long", "decimal"}, "minimum": 0},
+		"version":    bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+		"created_at": bson.M{"bsonType": "date"},
+		"updated_at": bson.M{"bsonType": "date"},
+		"deleted_at": bson.M{"bsonType": bson.A{"date", "null"}},
+	},
+}
+
+// applyItemsValidator aplica el schema validator a la colección (creándola si no existe)
+// validationLevel "moderate": los documentos viejos inválidos no bloquean sus updates
+func applyItemsValidator(ctx context.Context, db *mongo.Database, collection string) error {
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": collection})
+	if err != nil {
+		return fmt.Errorf("error listing collections: %w", err)
+	}
+
+	if len(names) == 0 {
+		opts := options.CreateCollection().
+			SetValidator(bson.M{"$jsonSchema": itemsSchema}).
+			SetValidationLevel("moderate").
+			SetValidationAction("error")
+		if err := db.CreateCollection(ctx, collection, opts); err != nil {
+			return fmt.Errorf("error creating collection with validator: %w", err)
+		}
+		return nil
+	}
+
+	cmd := bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "validator", Value: bson.M{"$jsonSchema": itemsSchema}},
+		{Key: "validationLevel", Value: "moderate"},
+		{Key: "validationAction", Value: "error"},
+	}
+	if err := db.RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("error applying validator: %w", err)
+	}
+	return nil
+}
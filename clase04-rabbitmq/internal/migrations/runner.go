@@ -0,0 +1,126 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration es un cambio versionado de esquema/datos en DB
+// Las versiones se aplican en orden ascendente y una sola vez
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// AppliedMigration es el registro guardado por cada migración aplicada
+type AppliedMigration struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// MigrationStatus indica si una migración conocida ya fue aplicada
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Runner aplica migraciones pendientes y registra cuáles se aplicaron
+// en la colección schema_migrations
+type Runner struct {
+	db         *mongo.Database
+	col        *mongo.Collection
+	migrations []Migration
+}
+
+// NewRunner crea un runner para las migraciones recibidas
+func NewRunner(db *mongo.Database, migrations []Migration) *Runner {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	return &Runner{
+		db:         db,
+		col:        db.Collection("schema_migrations"),
+		migrations: sorted,
+	}
+}
+
+// Up aplica en orden todas las migraciones pendientes
+// Se detiene en el primer error: las siguientes quedan pendientes
+func (r *Runner) Up(ctx context.Context) ([]Migration, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range r.migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		log.Printf("applying migration %d: %s", m.Version, m.Description)
+		if err := m.Up(ctx, r.db); err != nil {
+			return done, fmt.Errorf("error applying migration %d (%s): %w", m.Version, m.Description, err)
+		}
+
+		// 📝 Registramos la versión: _id único evita aplicarla dos veces
+		if _, err := r.col.InsertOne(ctx, AppliedMigration{
+			Version:     m.Version,
+			Description: m.Description,
+			AppliedAt:   time.Now().UTC(),
+		}); err != nil {
+			return done, fmt.Errorf("error recording migration %d: %w", m.Version, err)
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// Status lista todas las migraciones conocidas y cuándo se aplicaron
+func (r *Runner) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(r.migrations))
+	for i, m := range r.migrations {
+		statuses[i] = MigrationStatus{Migration: m}
+		if a, ok := applied[m.Version]; ok {
+			appliedAt := a.AppliedAt
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// applied retorna las migraciones ya registradas indexadas por versión
+func (r *Runner) applied(ctx context.Context) (map[int]AppliedMigration, error) {
+	cur, err := r.col.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("error reading applied migrations: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var records []AppliedMigration
+	if err := cur.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("error decoding applied migrations: %w", err)
+	}
+
+	applied := make(map[int]AppliedMigration, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+	return applied, nil
+}
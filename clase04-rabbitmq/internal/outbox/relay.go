@@ -0,0 +1,116 @@
+package outbox
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"context"
+	"log"
+	"time"
+)
+
+// Store es el almacenamiento de eventos pendientes (ver repository.MongoOutboxRepository)
+type Store interface {
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error)
+	MarkSent(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, id string, cause error, retryAt time.Time) error
+}
+
+// Publisher envía un evento al broker usando el ID del evento como MessageId,
+// así los reintentos de un mismo evento se pueden deduplicar del lado consumidor
+type Publisher interface {
+	Publish(ctx context.Context, event domain.CloudEvent) error
+}
+
+const (
+	// claimLease es cuánto queda reservado un evento mientras se publica
+	claimLease = 30 * time.Second
+
+	// maxBackoff es la espera máxima entre reintentos de un evento
+	maxBackoff = 5 * time.Minute
+
+	// publishTimeout acota cuánto espera un publish (ej.: RabbitMQ reconectando)
+	publishTimeout = 5 * time.Second
+)
+
+// Relay publica periódicamente los eventos pendientes del outbox
+// Un evento se marca como enviado recién cuando el broker lo aceptó: si el
+// proceso se cae en el medio se vuelve a publicar (entrega at-least-once)
+type Relay struct {
+	store     Store
+	publisher Publisher
+	interval  time.Duration
+	batchSize int
+}
+
+// NewRelay crea un relay que cada interval publica hasta batchSize eventos
+func NewRelay(store Store, publisher Publisher, interval time.Duration, batchSize int) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Run procesa el outbox hasta que se cancele ctx (pensado para correr en una goroutine)
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		// Vaciamos todo lo pendiente antes de esperar al próximo tick
+		for r.relayBatch(ctx) == r.batchSize {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relayBatch publica un lote de eventos y retorna cuántos tomó
+func (r *Relay) relayBatch(ctx context.Context) int {
+	events, err := r.store.ClaimPending(ctx, r.batchSize, claimLease)
+	if err != nil {
+		log.Printf("outbox: error claiming events: %v", err)
+	}
+
+	for _, event := range events {
+		if err := r.publish(ctx, event); err != nil {
+			retryAt := time.Now().Add(backoff(event.Attempts))
+			log.Printf("outbox: error publishing event %s (attempt %d), retrying at %s: %v",
+				event.ID, event.Attempts+1, retryAt.Format(time.RFC3339), err)
+			if err := r.store.MarkFailed(ctx, event.ID, err, retryAt); err != nil {
+				log.Printf("outbox: error marking event %s as failed: %v", event.ID, err)
+			}
+			continue
+		}
+
+		// ⚠️ Si esto falla el evento se va a volver a publicar al vencer el lease
+		if err := r.store.MarkSent(ctx, event.ID); err != nil {
+			log.Printf("outbox: error marking event %s as sent: %v", event.ID, err)
+		}
+	}
+
+	return len(events)
+}
+
+// publish envía un evento esperando como máximo publishTimeout
+func (r *Relay) publish(ctx context.Context, event domain.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	return r.publisher.Publish(ctx, event.Event)
+}
+
+// backoff es exponencial según los intentos fallidos: 1s, 2s, 4s... hasta maxBackoff
+func backoff(attempts int) time.Duration {
+	if attempts > 20 {
+		return maxBackoff
+	}
+	wait := time.Second << attempts
+	if wait > maxBackoff {
+		return maxBackoff
+	}
+	return wait
+}
@@ -0,0 +1,81 @@
+package pagination
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CursorCodec codifica y firma cursores de paginación
+// El cliente recibe un string opaco: no puede leerlo ni adulterarlo
+// Formato: base64url(payload JSON) + "." + base64url(HMAC-SHA256(payload))
+type CursorCodec struct {
+	secret []byte
+}
+
+// cursorPayload es lo que viaja dentro del cursor
+type cursorPayload struct {
+	CreatedAt int64  `json:"c"` // Unix nanos
+	ID        string `json:"i"`
+}
+
+// NewCursorCodec crea un codec que firma con el secreto recibido
+func NewCursorCodec(secret string) *CursorCodec {
+	return &CursorCodec{secret: []byte(secret)}
+}
+
+// Encode convierte un cursor en un string opaco y firmado
+func (c *CursorCodec) Encode(cursor domain.ItemsCursor) (string, error) {
+	payload, err := json.Marshal(cursorPayload{
+		CreatedAt: cursor.CreatedAt.UnixNano(),
+		ID:        cursor.ID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error marshalling cursor: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(c.sign(encoded)), nil
+}
+
+// Decode valida la firma y devuelve el cursor original
+// Cualquier cursor mal formado o adulterado se reporta como ErrInvalidQuery
+func (c *CursorCodec) Decode(value string) (domain.ItemsCursor, error) {
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return domain.ItemsCursor{}, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidQuery)
+	}
+
+	// 🔐 Comparación en tiempo constante de la firma
+	expected, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, c.sign(encoded)) {
+		return domain.ItemsCursor{}, fmt.Errorf("%w: invalid cursor signature", domain.ErrInvalidQuery)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return domain.ItemsCursor{}, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidQuery)
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return domain.ItemsCursor{}, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidQuery)
+	}
+
+	return domain.ItemsCursor{
+		CreatedAt: time.Unix(0, payload.CreatedAt).UTC(),
+		ID:        payload.ID,
+	}, nil
+}
+
+// sign calcula el HMAC del payload codificado
+func (c *CursorCodec) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,83 @@
+package pagination
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCursorCodecRoundTrip(t *testing.T) {
+	codec := NewCursorCodec("secret")
+
+	tests := []struct {
+		name   string
+		cursor domain.ItemsCursor
+	}{
+		{
+			name:   "con nanosegundos",
+			cursor: domain.ItemsCursor{CreatedAt: time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC), ID: "665f1c2e9b1d4a0001a1b2c3"},
+		},
+		{
+			name:   "fecha cero",
+			cursor: domain.ItemsCursor{CreatedAt: time.Unix(0, 0).UTC(), ID: "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := codec.Encode(tt.cursor)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			got, err := codec.Decode(value)
+			if err != nil {
+				t.Fatalf("Decode(%q) error = %v", value, err)
+			}
+			if !got.CreatedAt.Equal(tt.cursor.CreatedAt) || got.ID != tt.cursor.ID {
+				t.Errorf("Decode(Encode(%+v)) = %+v", tt.cursor, got)
+			}
+		})
+	}
+}
+
+func TestCursorCodecDecodeInvalid(t *testing.T) {
+	codec := NewCursorCodec("secret")
+	valid, err := codec.Encode(domain.ItemsCursor{CreatedAt: time.Now(), ID: "665f1c2e9b1d4a0001a1b2c3"})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	payload, signature, _ := strings.Cut(valid, ".")
+
+	// Payload que no es JSON pero con firma válida
+	notJSON := base64.RawURLEncoding.EncodeToString([]byte("not json"))
+	notJSONSigned := notJSON + "." + base64.RawURLEncoding.EncodeToString(codec.sign(notJSON))
+
+	otherSecret, err := NewCursorCodec("other").Encode(domain.ItemsCursor{CreatedAt: time.Now(), ID: "x"})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "vacío", value: ""},
+		{name: "sin separador", value: payload + signature},
+		{name: "payload adulterado", value: base64.RawURLEncoding.EncodeToString([]byte(`{"c":0,"i":"x"}`)) + "." + signature},
+		{name: "firma adulterada", value: payload + "." + base64.RawURLEncoding.EncodeToString([]byte("forged"))},
+		{name: "firma que no es base64", value: payload + ".!!!"},
+		{name: "firmado con otro secreto", value: otherSecret},
+		{name: "payload que no es JSON", value: notJSONSigned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := codec.Decode(tt.value); !errors.Is(err, domain.ErrInvalidQuery) {
+				t.Errorf("Decode(%q) error = %v, want ErrInvalidQuery", tt.value, err)
+			}
+		})
+	}
+}
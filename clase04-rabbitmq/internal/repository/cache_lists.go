@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// listKeyPrefix agrupa las claves de listados cacheados
+// Formato: items:list:<generación>:<hash del query normalizado>
+const listKeyPrefix = "items:list"
+
+// listKey arma la clave de un listado en la generación indicada
+// Cualquier escritura de items incrementa la generación: las claves de los
+// listados anteriores dejan de usarse y vencen solas por TTL
+func listKey(generation uint64, query domain.ItemsQuery) (string, error) {
+	bytes, err := json.Marshal(query)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling query to JSON: %w", err)
+	}
+	sum := sha256.Sum256(bytes)
+	return fmt.Sprintf("%s:%d:%s", listKeyPrefix, generation, hex.EncodeToString(sum[:])), nil
+}
+
+// cachedPage es una página de listado tal como se guarda en memcached
+// (domain.ItemsPage no serializa HasMore ni NextCursor)
+type cachedPage struct {
+	Items      []domain.Item       `json:"items"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page"`
+	Limit      int                 `json:"limit"`
+	HasMore    bool                `json:"has_more"`
+	NextCursor *domain.ItemsCursor `json:"next_cursor,omitempty"`
+}
+
+func cachedPageFromDomain(page domain.ItemsPage) cachedPage {
+	return cachedPage{
+		Items:      page.Items,
+		Total:      page.Total,
+		Page:       page.Page,
+		Limit:      page.Limit,
+		HasMore:    page.HasMore,
+		NextCursor: page.NextCursor,
+	}
+}
+
+func (p cachedPage) ToDomain() domain.ItemsPage {
+	return domain.ItemsPage{
+		Items:      p.Items,
+		Total:      p.Total,
+		Page:       p.Page,
+		Limit:      p.Limit,
+		HasMore:    p.HasMore,
+		NextCursor: p.NextCursor,
+	}
+}
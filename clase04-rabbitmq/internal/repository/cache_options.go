@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// earlyRefreshFactor es la fracción del TTL que, en promedio, se adelanta el
+// refresco de una entrada (expiración temprana probabilística)
+const earlyRefreshFactor = 0.1
+
+// CacheOptions configura el vencimiento de las entradas de las caches de items
+type CacheOptions struct {
+	TTL time.Duration // Tiempo en que una entrada está fresca
+	// Stale es el tiempo extra en que se sirve una entrada vencida mientras se
+	// refresca (stale-while-revalidate, 0 = deshabilitado)
+	Stale time.Duration
+	// NegativeTTL es el vencimiento de los tombstones (items borrados o
+	// inexistentes), 0 = el mismo TTL que el resto
+	NegativeTTL time.Duration
+}
+
+// cacheEntry es lo que guardan las caches: el item y hasta cuándo está fresco
+// El item va embebido para que las entradas viejas (sólo el item) se sigan leyendo
+type cacheEntry struct {
+	domain.Item
+	FreshUntil time.Time `json:"fresh_until,omitempty"`
+}
+
+// expiration es cuánto tiene que guardar la cache la entrada del item
+// 🚫 Los tombstones duran poco: sólo frenan lecturas repetidas de IDs inexistentes
+func (o CacheOptions) expiration(item domain.Item) time.Duration {
+	if item.IsDeleted() && o.NegativeTTL > 0 {
+		return o.NegativeTTL
+	}
+	return o.TTL + o.Stale
+}
+
+// freshUntil es hasta cuándo está fresca una entrada guardada ahora
+// Los tombstones no se refrescan (zero value): vencen y listo
+func (o CacheOptions) freshUntil(item domain.Item) time.Time {
+	if item.IsDeleted() {
+		return time.Time{}
+	}
+	return time.Now().Add(o.TTL)
+}
+
+// check retorna domain.ErrCacheStale si la entrada hay que refrescarla
+// Expiración temprana probabilística (XFetch): cuanto más cerca está el
+// vencimiento más probable es que una lectura la considere vencida, así los
+// refrescos de las claves populares no coinciden todos en el mismo instante
+func (o CacheOptions) check(freshUntil time.Time) error {
+	if o.Stale <= 0 || freshUntil.IsZero() {
+		return nil
+	}
+
+	// 1 - rand.Float64() está en (0, 1]: el logaritmo nunca es -Inf
+	early := time.Duration(float64(o.TTL) * earlyRefreshFactor * -math.Log(1-rand.Float64()))
+	if time.Now().Add(early).Before(freshUntil) {
+		return nil
+	}
+	return domain.ErrCacheStale
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCacheOptionsCheck(t *testing.T) {
+	const ttl = time.Minute
+
+	tests := []struct {
+		name       string
+		options    CacheOptions
+		freshUntil time.Time
+		wantStale  bool
+	}{
+		{
+			name:       "sin stale nunca se refresca antes",
+			options:    CacheOptions{TTL: ttl},
+			freshUntil: time.Now().Add(-time.Hour),
+		},
+		{
+			name:       "tombstone (sin vencimiento)",
+			options:    CacheOptions{TTL: ttl, Stale: ttl},
+			freshUntil: time.Time{},
+		},
+		{
+			name:       "ya vencida",
+			options:    CacheOptions{TTL: ttl, Stale: ttl},
+			freshUntil: time.Now().Add(-time.Second),
+			wantStale:  true,
+		},
+		{
+			// El adelanto promedio es 10% del TTL: a 100 TTLs es prácticamente imposible
+			name:       "lejos del vencimiento",
+			options:    CacheOptions{TTL: ttl, Stale: ttl},
+			freshUntil: time.Now().Add(100 * ttl),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// check es aleatorio: lo corremos varias veces
+			for i := 0; i < 1000; i++ {
+				err := tt.options.check(tt.freshUntil)
+				if stale := errors.Is(err, domain.ErrCacheStale); stale != tt.wantStale || (!stale && err != nil) {
+					t.Fatalf("check() = %v, want stale=%v", err, tt.wantStale)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheOptionsCheckEarlyRefresh(t *testing.T) {
+	options := CacheOptions{TTL: time.Minute, Stale: time.Minute}
+
+	// A earlyRefreshFactor*TTL del vencimiento se refresca con probabilidad 1/e (~37%)
+	freshUntil := time.Now().Add(time.Duration(float64(options.TTL) * earlyRefreshFactor))
+
+	const runs = 5000
+	stale := 0
+	for i := 0; i < runs; i++ {
+		if errors.Is(options.check(freshUntil), domain.ErrCacheStale) {
+			stale++
+		}
+	}
+	if share := float64(stale) / runs; share < 0.25 || share > 0.5 {
+		t.Errorf("early refresh rate = %.2f, want ~0.37", share)
+	}
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"clase04-rabbitmq/internal/dao"
+	"clase04-rabbitmq/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoIdempotencyRepository guarda las respuestas de los requests con Idempotency-Key
+// Implementa middleware.IdempotencyStore
+type MongoIdempotencyRepository struct {
+	col *mongo.Collection
+}
+
+// NewMongoIdempotencyRepository crea el repository sobre la colección indicada
+// Las claves se borran solas después de ttl (índice TTL): pasado ese tiempo
+// un reintento con la misma clave se procesa como un request nuevo
+func NewMongoIdempotencyRepository(ctx context.Context, db *mongo.Database, collectionName string, ttl time.Duration) *MongoIdempotencyRepository {
+	repo := &MongoIdempotencyRepository{col: db.Collection(collectionName)}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := repo.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetName("created_ttl").SetExpireAfterSeconds(int32(ttl.Seconds())),
+	})
+	if err != nil {
+		log.Printf("⚠️ could not create idempotency keys TTL index: %v", err)
+	}
+
+	return repo
+}
+
+// Begin reserva la clave para procesar el request
+// Retorna acquired=true si el llamador tiene que procesarlo; si no, retorna el
+// registro existente (completo, en proceso o de otro request)
+// Un registro en proceso cuyo lease venció (ej.: la réplica se cayó) se puede
+// volver a tomar con el mismo request
+func (r *MongoIdempotencyRepository) Begin(ctx context.Context, key string, requestHash string, lease time.Duration) (domain.IdempotencyRecord, bool, error) {
+	now := time.Now().UTC()
+	record := dao.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		LockedUntil: now.Add(lease),
+		CreatedAt:   now,
+	}
+
+	_, err := r.col.InsertOne(ctx, record)
+	if err == nil {
+		return record.ToDomain(), true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return domain.IdempotencyRecord{}, false, fmt.Errorf("error creating idempotency key: %w", err)
+	}
+
+	// 🔁 Ya existe: tomamos el lease vencido o leemos lo guardado
+	var existing dao.IdempotencyRecord
+	err = r.col.FindOneAndUpdate(ctx,
+		bson.M{"_id": key, "request_hash": requestHash, "completed": false, "locked_until": bson.M{"$lt": now}},
+		bson.M{"$set": bson.M{"locked_until": now.Add(lease)}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&existing)
+	if err == nil {
+		return existing.ToDomain(), true, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.IdempotencyRecord{}, false, fmt.Errorf("error taking over idempotency key: %w", err)
+	}
+
+	if err := r.col.FindOne(ctx, bson.M{"_id": key}).Decode(&existing); err != nil {
+		return domain.IdempotencyRecord{}, false, fmt.Errorf("error reading idempotency key: %w", err)
+	}
+	return existing.ToDomain(), false, nil
+}
+
+// Complete guarda la respuesta del request para repetirla en los reintentos
+func (r *MongoIdempotencyRepository) Complete(ctx context.Context, record domain.IdempotencyRecord) error {
+	if _, err := r.col.UpdateOne(ctx, bson.M{"_id": record.Key}, bson.M{"$set": bson.M{
+		"completed":   true,
+		"status_code": record.StatusCode,
+		"headers":     record.Headers,
+		"body":        record.Body,
+	}}); err != nil {
+		return fmt.Errorf("error completing idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release libera una clave en proceso (el request falló y se puede reintentar)
+func (r *MongoIdempotencyRepository) Release(ctx context.Context, key string) error {
+	if _, err := r.col.DeleteOne(ctx, bson.M{"_id": key, "completed": false}); err != nil {
+		return fmt.Errorf("error releasing idempotency key: %w", err)
+	}
+	return nil
+}
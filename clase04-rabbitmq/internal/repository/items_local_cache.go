@@ -5,48 +5,137 @@ import (
 	"context"
 	"fmt"
 	"github.com/karlseguin/ccache"
-	"time"
+	"sync/atomic"
 )
 
 type ItemsLocalCacheRepository struct {
-	client *ccache.Cache
-	ttl    time.Duration
+	client     *ccache.Cache
+	options    CacheOptions
+	generation *atomic.Uint64 // Generación actual de los listados cacheados
 }
 
-func NewItemsLocalCacheRepository(ttl time.Duration) *ItemsLocalCacheRepository {
+// NewItemsLocalCacheRepository crea la cache con el vencimiento de las entradas
+func NewItemsLocalCacheRepository(options CacheOptions) *ItemsLocalCacheRepository {
 	return &ItemsLocalCacheRepository{
-		client: ccache.New(ccache.Configure()),
-		ttl:    ttl,
+		client:     ccache.New(ccache.Configure()),
+		options:    options,
+		generation: &atomic.Uint64{},
 	}
 }
 
-func (r ItemsLocalCacheRepository) List(ctx context.Context) ([]domain.Item, error) {
-	return nil, fmt.Errorf("list is not supported in memcached")
+// List retorna la página cacheada para el query (domain.ErrCacheMiss si no está)
+func (r ItemsLocalCacheRepository) List(ctx context.Context, query domain.ItemsQuery) (domain.ItemsPage, error) {
+	key, err := r.ListKey(ctx, query)
+	if err != nil {
+		return domain.ItemsPage{}, err
+	}
+	return r.GetList(ctx, key)
+}
+
+// ListKey arma la clave del query en la generación actual de listados
+// La generación es propia de cada réplica: las escrituras de las otras
+// la incrementan a través del worker.CacheInvalidator
+func (r ItemsLocalCacheRepository) ListKey(ctx context.Context, query domain.ItemsQuery) (string, error) {
+	return listKey(r.generation.Load(), query)
+}
+
+// GetList lee una página guardada con SetList
+func (r ItemsLocalCacheRepository) GetList(ctx context.Context, key string) (domain.ItemsPage, error) {
+	it := r.client.Get(key)
+	if it == nil || it.Expired() {
+		return domain.ItemsPage{}, domain.ErrCacheMiss
+	}
+	page, ok := it.Value().(domain.ItemsPage)
+	if !ok {
+		return domain.ItemsPage{}, fmt.Errorf("error asserting list type from cache")
+	}
+	return page, nil
+}
+
+// SetList guarda una página bajo la clave obtenida con ListKey
+func (r ItemsLocalCacheRepository) SetList(ctx context.Context, key string, page domain.ItemsPage) error {
+	r.client.Set(key, page, r.options.TTL)
+	return nil
+}
+
+// InvalidateLists pasa a una generación nueva: los listados cacheados dejan de usarse
+func (r ItemsLocalCacheRepository) InvalidateLists(ctx context.Context) error {
+	r.generation.Add(1)
+	return nil
 }
 
 func (r ItemsLocalCacheRepository) Create(ctx context.Context, item domain.Item) (domain.Item, error) {
-	r.client.Set(item.ID, item, r.ttl)
+	r.client.Set(item.ID, item, r.options.expiration(item))
 	return item, nil
 }
 
 func (r ItemsLocalCacheRepository) GetByID(ctx context.Context, id string) (domain.Item, error) {
 	it := r.client.Get(id)
-	if it == nil {
-		return domain.Item{}, fmt.Errorf("item not found in cache")
+	if it == nil || it.Expired() {
+		return domain.Item{}, domain.ErrCacheMiss
 	}
 	item, ok := it.Value().(domain.Item)
 	if !ok {
 		return domain.Item{}, fmt.Errorf("error asserting item type from cache")
 	}
-	return item, nil
+	if item.IsDeleted() {
+		return item, nil
+	}
+	// CCache guarda el vencimiento total (TTL + stale)
+	return item, r.options.check(it.Expires().Add(-r.options.Stale))
 }
 
+// Update reemplaza el item sólo si ya estaba en cache (conserva su vencimiento)
 func (r ItemsLocalCacheRepository) Update(ctx context.Context, id string, item domain.Item) (domain.Item, error) {
-	//TODO implement me
-	panic("implement me")
+	if !r.client.Replace(id, item) {
+		return domain.Item{}, domain.ErrCacheMiss
+	}
+	return item, nil
+}
+
+// Delete invalida la entrada del item (version se ignora en la cache)
+func (r ItemsLocalCacheRepository) Delete(ctx context.Context, id string, version int64) error {
+	if !r.client.Delete(id) {
+		return domain.ErrCacheMiss
+	}
+	return nil
+}
+
+func (r ItemsLocalCacheRepository) Restore(ctx context.Context, id string) (domain.Item, error) {
+	return domain.Item{}, fmt.Errorf("%w: restore in local cache", domain.ErrNotSupported)
+}
+
+func (r ItemsLocalCacheRepository) Purge(ctx context.Context, id string, version int64) error {
+	return fmt.Errorf("%w: purge in local cache", domain.ErrNotSupported)
+}
+
+// Search no está soportado: la cache no puede rankear por relevancia
+func (r ItemsLocalCacheRepository) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchHit, error) {
+	return nil, fmt.Errorf("%w: search in local cache", domain.ErrNotSupported)
+}
+
+func (r ItemsLocalCacheRepository) CreateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	results := make([]domain.BatchResult, len(items))
+	for i, item := range items {
+		r.client.Set(item.ID, item, r.options.expiration(item))
+		cached := item
+		results[i] = domain.BatchResult{Index: i, ID: item.ID, Item: &cached}
+	}
+	return results, nil
+}
+
+func (r ItemsLocalCacheRepository) UpdateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	return nil, fmt.Errorf("%w: batch update in local cache", domain.ErrNotSupported)
+}
+
+func (r ItemsLocalCacheRepository) DeleteBatch(ctx context.Context, refs []domain.ItemRef) ([]domain.BatchResult, error) {
+	return nil, fmt.Errorf("%w: batch delete in local cache", domain.ErrNotSupported)
+}
+
+func (r ItemsLocalCacheRepository) UpsertBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	return nil, fmt.Errorf("%w: batch upsert in local cache", domain.ErrNotSupported)
 }
 
-func (r ItemsLocalCacheRepository) Delete(ctx context.Context, id string) error {
-	//TODO implement me
-	panic("implement me")
+func (r ItemsLocalCacheRepository) Stream(ctx context.Context, fn func(domain.Item) error) error {
+	return fmt.Errorf("%w: stream in local cache", domain.ErrNotSupported)
 }
@@ -4,39 +4,102 @@ import (
 	"clase04-rabbitmq/internal/domain"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/bradfitz/gomemcache/memcache"
+	"strconv"
 	"time"
 )
 
+// listGenerationKey guarda la generación actual de los listados cacheados
+const listGenerationKey = listKeyPrefix + ":generation"
+
 type MemcachedItemsRepository struct {
-	ttl    time.Duration
-	client *memcache.Client
+	options CacheOptions
+	client  *memcache.Client
 }
 
-func NewMemcachedItemsRepository(host string, port string, ttl time.Duration) MemcachedItemsRepository {
-	client := memcache.New(fmt.Sprintf("%s:%s", host, port))
+// NewMemcachedItemsRepository crea la cache con el vencimiento de las entradas
+// servers elige el nodo de cada clave (ej.: clients.MemcachedCluster)
+func NewMemcachedItemsRepository(servers memcache.ServerSelector, options CacheOptions) MemcachedItemsRepository {
+	client := memcache.NewFromSelector(servers)
 
 	return MemcachedItemsRepository{
-		client: client,
-		ttl:    ttl,
+		client:  client,
+		options: options,
 	}
 }
 
-func (r MemcachedItemsRepository) List(ctx context.Context) ([]domain.Item, error) {
-	return nil, fmt.Errorf("list is not supported in memcached")
+// List retorna la página cacheada para el query (domain.ErrCacheMiss si no está)
+func (r MemcachedItemsRepository) List(ctx context.Context, query domain.ItemsQuery) (domain.ItemsPage, error) {
+	key, err := r.ListKey(ctx, query)
+	if err != nil {
+		return domain.ItemsPage{}, err
+	}
+	return r.GetList(ctx, key)
 }
 
-func (r MemcachedItemsRepository) Create(ctx context.Context, item domain.Item) (domain.Item, error) {
-	bytes, err := json.Marshal(item)
+// ListKey arma la clave del query en la generación actual de listados
+// La generación se comparte entre réplicas en la clave listGenerationKey
+func (r MemcachedItemsRepository) ListKey(ctx context.Context, query domain.ItemsQuery) (string, error) {
+	generation, err := r.listGeneration()
+	if err != nil {
+		return "", err
+	}
+	return listKey(generation, query)
+}
+
+// GetList lee una página guardada con SetList
+func (r MemcachedItemsRepository) GetList(ctx context.Context, key string) (domain.ItemsPage, error) {
+	bytes, err := r.client.Get(key)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return domain.ItemsPage{}, domain.ErrCacheMiss
+	}
 	if err != nil {
-		return domain.Item{}, fmt.Errorf("error marshalling item to JSON: %w", err)
+		return domain.ItemsPage{}, fmt.Errorf("error getting list from memcached: %w", err)
+	}
+	var page cachedPage
+	if err := json.Unmarshal(bytes.Value, &page); err != nil {
+		return domain.ItemsPage{}, fmt.Errorf("error unmarshalling list from JSON: %w", err)
+	}
+	return page.ToDomain(), nil
+}
+
+// SetList guarda una página bajo la clave obtenida con ListKey
+func (r MemcachedItemsRepository) SetList(ctx context.Context, key string, page domain.ItemsPage) error {
+	bytes, err := json.Marshal(cachedPageFromDomain(page))
+	if err != nil {
+		return fmt.Errorf("error marshalling list to JSON: %w", err)
 	}
 	if err := r.client.Set(&memcache.Item{
-		Key:        item.ID,
+		Key:        key,
 		Value:      bytes,
-		Expiration: int32(r.ttl.Seconds()),
+		Expiration: int32(r.options.TTL.Seconds()),
 	}); err != nil {
+		return fmt.Errorf("error setting list in memcached: %w", err)
+	}
+	return nil
+}
+
+// InvalidateLists pasa a una generación nueva: los listados cacheados dejan de usarse
+func (r MemcachedItemsRepository) InvalidateLists(ctx context.Context) error {
+	_, err := r.client.Increment(listGenerationKey, 1)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		// Sin generación no hay listados cacheados: la próxima lectura crea una nueva
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error incrementing list generation in memcached: %w", err)
+	}
+	return nil
+}
+
+func (r MemcachedItemsRepository) Create(ctx context.Context, item domain.Item) (domain.Item, error) {
+	entry, err := r.entry(item.ID, item)
+	if err != nil {
+		return domain.Item{}, err
+	}
+	if err := r.client.Set(entry); err != nil {
 		return domain.Item{}, fmt.Errorf("error setting item in memcached: %w", err)
 	}
 	return item, nil
@@ -44,22 +107,131 @@ func (r MemcachedItemsRepository) Create(ctx context.Context, item domain.Item)
 
 func (r MemcachedItemsRepository) GetByID(ctx context.Context, id string) (domain.Item, error) {
 	bytes, err := r.client.Get(id)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return domain.Item{}, domain.ErrCacheMiss
+	}
 	if err != nil {
 		return domain.Item{}, fmt.Errorf("error getting item from memcached: %w", err)
 	}
-	var item domain.Item
-	if err := json.Unmarshal(bytes.Value, &item); err != nil {
+	var entry cacheEntry
+	if err := json.Unmarshal(bytes.Value, &entry); err != nil {
 		return domain.Item{}, fmt.Errorf("error unmarshalling item from JSON: %w", err)
 	}
-	return item, nil
+	return entry.Item, r.options.check(entry.FreshUntil)
 }
 
+// Update reemplaza el item sólo si ya estaba en memcached
+// Si no estaba no se agrega: se cachea en el próximo GetByID
 func (r MemcachedItemsRepository) Update(ctx context.Context, id string, item domain.Item) (domain.Item, error) {
-	//TODO implement me
-	panic("implement me")
+	entry, err := r.entry(id, item)
+	if err != nil {
+		return domain.Item{}, err
+	}
+	err = r.client.Replace(entry)
+	if errors.Is(err, memcache.ErrNotStored) {
+		return domain.Item{}, domain.ErrCacheMiss
+	}
+	if err != nil {
+		return domain.Item{}, fmt.Errorf("error replacing item in memcached: %w", err)
+	}
+	return item, nil
 }
 
-func (r MemcachedItemsRepository) Delete(ctx context.Context, id string) error {
-	//TODO implement me
-	panic("implement me")
+// Delete invalida la entrada del item (version se ignora en la cache)
+func (r MemcachedItemsRepository) Delete(ctx context.Context, id string, version int64) error {
+	err := r.client.Delete(id)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return domain.ErrCacheMiss
+	}
+	if err != nil {
+		return fmt.Errorf("error deleting item from memcached: %w", err)
+	}
+	return nil
+}
+
+func (r MemcachedItemsRepository) Restore(ctx context.Context, id string) (domain.Item, error) {
+	return domain.Item{}, fmt.Errorf("%w: restore in memcached", domain.ErrNotSupported)
+}
+
+func (r MemcachedItemsRepository) Purge(ctx context.Context, id string, version int64) error {
+	return fmt.Errorf("%w: purge in memcached", domain.ErrNotSupported)
+}
+
+// Search no está soportado: la cache no puede rankear por relevancia
+func (r MemcachedItemsRepository) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchHit, error) {
+	return nil, fmt.Errorf("%w: search in memcached", domain.ErrNotSupported)
+}
+
+// CreateBatch guarda varios items en memcached
+// gomemcache no tiene multi-set: son N Set sobre las mismas conexiones del pool
+func (r MemcachedItemsRepository) CreateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	results := make([]domain.BatchResult, len(items))
+	for i, item := range items {
+		results[i] = domain.BatchResult{Index: i, ID: item.ID}
+		created, err := r.Create(ctx, item)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Item = &created
+	}
+	return results, nil
+}
+
+func (r MemcachedItemsRepository) UpdateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	return nil, fmt.Errorf("%w: batch update in memcached", domain.ErrNotSupported)
+}
+
+func (r MemcachedItemsRepository) DeleteBatch(ctx context.Context, refs []domain.ItemRef) ([]domain.BatchResult, error) {
+	return nil, fmt.Errorf("%w: batch delete in memcached", domain.ErrNotSupported)
+}
+
+func (r MemcachedItemsRepository) UpsertBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	return nil, fmt.Errorf("%w: batch upsert in memcached", domain.ErrNotSupported)
+}
+
+func (r MemcachedItemsRepository) Stream(ctx context.Context, fn func(domain.Item) error) error {
+	return fmt.Errorf("%w: stream in memcached", domain.ErrNotSupported)
+}
+
+// entry arma la entrada de memcached para el item (JSON con su vencimiento)
+func (r MemcachedItemsRepository) entry(key string, item domain.Item) (*memcache.Item, error) {
+	bytes, err := json.Marshal(cacheEntry{Item: item, FreshUntil: r.options.freshUntil(item)})
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling item to JSON: %w", err)
+	}
+	return &memcache.Item{
+		Key:        key,
+		Value:      bytes,
+		Expiration: int32(r.options.expiration(item).Seconds()),
+	}, nil
+}
+
+// listGeneration lee la generación actual de listados, creándola si no existe
+// Arranca en un valor basado en el reloj (no en 0): si memcached descarta la
+// clave no se vuelven a usar las claves de una generación anterior
+func (r MemcachedItemsRepository) listGeneration() (uint64, error) {
+	it, err := r.client.Get(listGenerationKey)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		generation := uint64(time.Now().UnixNano())
+		err = r.client.Add(&memcache.Item{
+			Key:   listGenerationKey,
+			Value: []byte(strconv.FormatUint(generation, 10)),
+		})
+		if errors.Is(err, memcache.ErrNotStored) {
+			// Otra réplica la creó al mismo tiempo: usamos la suya
+			it, err = r.client.Get(listGenerationKey)
+		} else if err == nil {
+			return generation, nil
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error getting list generation from memcached: %w", err)
+	}
+
+	generation, err := strconv.ParseUint(string(it.Value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing list generation: %w", err)
+	}
+	return generation, nil
 }
@@ -5,22 +5,30 @@ import (
 	"clase04-rabbitmq/internal/domain"
 	"context"
 	"errors"
+	"fmt"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // MongoItemsRepository implementa ItemsRepository usando DB
 type MongoItemsRepository struct {
-	col *mongo.Collection // Referencia a la colección "items" en DB
+	col          *mongo.Collection // Referencia a la colección "items" en DB
+	transactions bool              // true si el servidor soporta transacciones (replica set)
 }
 
 // NewMongoItemsRepository crea una nueva instancia del repository
 // Recibe una referencia a la base de datos DB
-func NewMongoItemsRepository(ctx context.Context, uri, dbName, collectionName string) *MongoItemsRepository {
+// Sin replica set no hay transacciones: falla salvo que allowNoTransactions
+// sea true (MONGO_ALLOW_NO_TRANSACTIONS, sólo para desarrollo)
+func NewMongoItemsRepository(ctx context.Context, uri, dbName, collectionName string, allowNoTransactions bool) *MongoItemsRepository {
 	opt := options.Client().ApplyURI(uri)
 	opt.SetServerSelectionTimeout(10 * time.Second)
 
@@ -37,23 +45,137 @@ func NewMongoItemsRepository(ctx context.Context, uri, dbName, collectionName st
 		return nil
 	}
 
-	return &MongoItemsRepository{
+	repo := &MongoItemsRepository{
 		col: client.Database(dbName).Collection(collectionName), // Conecta con la colección "items"
 	}
+
+	// 🔒 Las transacciones solo existen en replica sets (ver docker-compose)
+	repo.transactions = supportsTransactions(pingCtx, client)
+	if !repo.transactions {
+		if !allowNoTransactions {
+			log.Fatalf("Error: DB is not a replica set and does not support transactions (set MONGO_ALLOW_NO_TRANSACTIONS=true to run without them)")
+			return nil
+		}
+		log.Printf("⚠️ DB is not a replica set: writes and outbox events will not be atomic (MONGO_ALLOW_NO_TRANSACTIONS)")
+	}
+
+	// 🔎 Índice de texto para Search; si falla la búsqueda usa regex como fallback
+	if err := repo.ensureTextIndex(ctx); err != nil {
+		log.Printf("⚠️ could not create text index, search will fall back to regex: %v", err)
+	}
+
+	return repo
+}
+
+// Database retorna la base de datos del repository (para compartir el cliente)
+func (r *MongoItemsRepository) Database() *mongo.Database {
+	return r.col.Database()
+}
+
+// WithTransaction ejecuta fn dentro de una transacción de DB
+// Todas las operaciones que usen el ctx recibido por fn (en cualquier colección
+// de este cliente) se confirman o descartan juntas. Si el servidor no soporta
+// transacciones (sólo con MONGO_ALLOW_NO_TRANSACTIONS), fn se ejecuta sin transacción
+func (r *MongoItemsRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !r.transactions {
+		return fn(ctx)
+	}
+
+	session, err := r.col.Database().Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("error starting DB session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	// WithTransaction reintenta fn ante errores transitorios y hace commit/abort
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
+
+// supportsTransactions consulta el comando hello: hay transacciones si el
+// servidor es parte de un replica set (setName) o es un mongos
+func supportsTransactions(ctx context.Context, client *mongo.Client) bool {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false
+	}
+	return hello.SetName != "" || hello.Msg == "isdbgrid"
 }
 
-// List obtiene todos los items de DB
-func (r *MongoItemsRepository) List(ctx context.Context) ([]domain.Item, error) {
+// textIndexName es el nombre del índice de texto usado por Search
+const textIndexName = "items_text"
+
+// ensureTextIndex crea (si no existe) el índice de texto sobre los campos buscables
+// Para sumar campos (ej.: description) agregarlos acá con su peso
+func (r *MongoItemsRepository) ensureTextIndex(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := r.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "name", Value: "text"}},
+		Options: options.Index().
+			SetName(textIndexName).
+			SetWeights(bson.M{"name": 10}),
+	})
+	return err
+}
+
+// List obtiene una página de items de DB aplicando filtros y orden
+// Soporta dos modos de paginación:
+// - Offset (page/limit): skip + limit, simple pero inestable con inserts concurrentes
+// - Keyset (query.After): trae los items posteriores al cursor en orden (created_at, _id)
+func (r *MongoItemsRepository) List(ctx context.Context, query domain.ItemsQuery) (domain.ItemsPage, error) {
 	// ⏰ Timeout para evitar que la operación se cuelgue
 	// Esto es importante en producción para no bloquear indefinidamente
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	// 🔍 Find() sin filtros retorna todos los documentos de la colección
-	// bson.M{} es un filtro vacío (equivale a {} en DB shell)
-	cur, err := r.col.Find(ctx, bson.M{})
+	filter := listFilter(query)
+
+	// 🔢 Total de documentos que cumplen el filtro (para el envelope de respuesta)
+	total, err := r.col.CountDocuments(ctx, filter)
 	if err != nil {
-		return nil, err
+		return domain.ItemsPage{}, fmt.Errorf("error counting items in DB: %w", err)
+	}
+
+	// ↕️ Orden por el campo pedido + _id como desempate para que el orden sea estable
+	direction := 1
+	if query.SortDesc {
+		direction = -1
+	}
+	sort := bson.D{{Key: "_id", Value: direction}}
+	if query.SortBy != "" { // El service usa created_at por defecto
+		sort = bson.D{{Key: query.SortBy, Value: direction}, {Key: "_id", Value: direction}}
+	}
+
+	// 📄 Pedimos un item de más para saber si hay una página siguiente
+	opts := options.Find().
+		SetSort(sort).
+		SetLimit(int64(query.Limit + 1))
+
+	if query.After != nil {
+		// 🔑 Keyset: (created_at, _id) > (cursor.CreatedAt, cursor.ID)
+		afterID, err := primitive.ObjectIDFromHex(query.After.ID)
+		if err != nil {
+			return domain.ItemsPage{}, fmt.Errorf("%w: invalid cursor id", domain.ErrInvalidQuery)
+		}
+		filter = bson.M{"$and": bson.A{filter, bson.M{"$or": bson.A{
+			bson.M{"created_at": bson.M{"$gt": query.After.CreatedAt}},
+			bson.M{"created_at": query.After.CreatedAt, "_id": bson.M{"$gt": afterID}},
+		}}}}
+	} else {
+		// Offset: salteamos las páginas anteriores
+		opts.SetSkip(int64((query.Page - 1) * query.Limit))
+	}
+
+	cur, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return domain.ItemsPage{}, err
 	}
 	defer cur.Close(ctx) // ⚠️ IMPORTANTE: Siempre cerrar el cursor para liberar recursos
 
@@ -61,7 +183,12 @@ func (r *MongoItemsRepository) List(ctx context.Context) ([]domain.Item, error)
 	// Usamos el modelo DAO porque maneja ObjectID y tags BSON
 	var daoItems []dao.Item
 	if err := cur.All(ctx, &daoItems); err != nil {
-		return nil, err
+		return domain.ItemsPage{}, err
+	}
+
+	hasMore := len(daoItems) > query.Limit
+	if hasMore {
+		daoItems = daoItems[:query.Limit]
 	}
 
 	// 🔄 Convertir de DAO a Domain (para la capa de negocio)
@@ -71,29 +198,768 @@ func (r *MongoItemsRepository) List(ctx context.Context) ([]domain.Item, error)
 		domainItems[i] = daoItem.ToDomain() // Función definida en dao/Item.go
 	}
 
-	return domainItems, nil
+	page := domain.ItemsPage{
+		Items:   domainItems,
+		Total:   total,
+		Page:    query.Page,
+		Limit:   query.Limit,
+		HasMore: hasMore,
+	}
+
+	// 🧭 El cursor solo tiene sentido si el orden coincide con el del keyset
+	if hasMore && query.SortBy == "created_at" && !query.SortDesc {
+		last := domainItems[len(domainItems)-1]
+		page.NextCursor = &domain.ItemsCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return page, nil
+}
+
+// listFilter traduce un ItemsQuery al filtro BSON equivalente
+// Ej.: {name: {$regex: "cof", $options: "i"}, price: {$gte: 1, $lte: 10}}
+func listFilter(query domain.ItemsQuery) bson.M {
+	filter := bson.M{}
+
+	// 🗑️ Por defecto se excluyen los borrados lógicos
+	// {deleted_at: null} matchea tanto null como campo inexistente
+	if !query.IncludeDeleted {
+		filter["deleted_at"] = nil
+	}
+
+	// 🔍 Substring case-insensitive; QuoteMeta evita que el input se interprete como regex
+	if query.Name != "" {
+		filter["name"] = bson.M{"$regex": regexp.QuoteMeta(query.Name), "$options": "i"}
+	}
+
+	// 💰 Rango de precios (cada extremo es opcional)
+	price := bson.M{}
+	if query.MinPrice != nil {
+		price["$gte"] = *query.MinPrice
+	}
+	if query.MaxPrice != nil {
+		price["$lte"] = *query.MaxPrice
+	}
+	if len(price) > 0 {
+		filter["price"] = price
+	}
+
+	return filter
 }
 
 // Create inserta un nuevo item en DB
 // Consigna 1: Validar name y price >= 0, agregar timestamps
 func (r *MongoItemsRepository) Create(ctx context.Context, item domain.Item) (domain.Item, error) {
-	return domain.Item{}, errors.New("TODO: implementar Create")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// 🕒 Timestamps en UTC, los setea el repository (no el cliente)
+	now := time.Now().UTC()
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	item.Version = 1
+
+	// 🔄 Domain -> DAO, generando un ObjectID nuevo
+	daoItem := dao.FromDomain(item)
+	daoItem.ID = primitive.NewObjectID()
+
+	if _, err := r.col.InsertOne(ctx, daoItem); err != nil {
+		// Índice único de name (ver migrations): duplicado -> 409
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.Item{}, domain.ErrAlreadyExists
+		}
+		return domain.Item{}, fmt.Errorf("error inserting item in DB: %w", err)
+	}
+
+	return daoItem.ToDomain(), nil
 }
 
 // GetByID busca un item por su ID
 // Consigna 2: Validar que el ID sea un ObjectID válido
 func (r *MongoItemsRepository) GetByID(ctx context.Context, id string) (domain.Item, error) {
-	return domain.Item{}, errors.New("TODO: implementar GetByID")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Item{}, domain.ErrInvalidID
+	}
+
+	// 🗑️ Un item borrado lógicamente se comporta como inexistente
+	var daoItem dao.Item
+	if err := r.col.FindOne(ctx, bson.M{"_id": objectID, "deleted_at": nil}).Decode(&daoItem); err != nil {
+		// 🔍 ErrNoDocuments -> error de dominio para que el controller responda 404
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.Item{}, domain.ErrNotFound
+		}
+		return domain.Item{}, fmt.Errorf("error finding item in DB: %w", err)
+	}
+
+	return daoItem.ToDomain(), nil
 }
 
 // Update actualiza un item existente
 // Consigna 3: Update parcial + actualizar updatedAt
+// Si item.Version > 0 el update es condicional: solo se aplica si la versión
+// en DB coincide (control de concurrencia optimista), si no retorna ErrVersionConflict
 func (r *MongoItemsRepository) Update(ctx context.Context, id string, item domain.Item) (domain.Item, error) {
-	return domain.Item{}, errors.New("TODO: implementar Update")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Item{}, domain.ErrInvalidID
+	}
+
+	// ✏️ $set solo sobre los campos editables: _id y created_at no se tocan
+	// $inc de version: cada escritura invalida las versiones leídas antes
+	update := bson.M{
+		"$set": bson.M{
+			"name":       item.Name,
+			"price":      item.Price,
+			"updated_at": time.Now().UTC(),
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	// ReturnDocument(After) devuelve el documento ya actualizado
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var daoItem dao.Item
+	if err := r.col.FindOneAndUpdate(ctx, activeFilter(objectID, item.Version), update, opts).Decode(&daoItem); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.Item{}, r.missError(ctx, bson.M{"_id": objectID, "deleted_at": nil})
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.Item{}, domain.ErrAlreadyExists
+		}
+		return domain.Item{}, fmt.Errorf("error updating item in DB: %w", err)
+	}
+
+	return daoItem.ToDomain(), nil
 }
 
 // Delete elimina un item por ID
 // Consigna 4: Eliminar documento de DB
-func (r *MongoItemsRepository) Delete(ctx context.Context, id string) error {
-	return errors.New("TODO: implementar Delete")
+// Es un borrado lógico: marca deleted_at y el item deja de aparecer en List/GetByID,
+// pero puede recuperarse con Restore. Para borrar físicamente usar Purge
+// version > 0 hace el borrado condicional, igual que en Update
+func (r *MongoItemsRepository) Delete(ctx context.Context, id string, version int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrInvalidID
+	}
+
+	now := time.Now().UTC()
+	update := bson.M{
+		"$set": bson.M{"deleted_at": now, "updated_at": now},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := r.col.UpdateOne(ctx, activeFilter(objectID, version), update)
+	if err != nil {
+		return fmt.Errorf("error deleting item in DB: %w", err)
+	}
+
+	// MatchedCount == 0: no existe, ya estaba borrado o cambió de versión
+	if result.MatchedCount == 0 {
+		return r.missError(ctx, bson.M{"_id": objectID, "deleted_at": nil})
+	}
+
+	return nil
+}
+
+// Restore recupera un item borrado lógicamente
+// Si el item está activo se retorna tal cual está junto con domain.ErrNotDeleted
+func (r *MongoItemsRepository) Restore(ctx context.Context, id string) (domain.Item, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Item{}, domain.ErrInvalidID
+	}
+
+	update := bson.M{
+		"$unset": bson.M{"deleted_at": ""},
+		"$set":   bson.M{"updated_at": time.Now().UTC()},
+		"$inc":   bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var daoItem dao.Item
+	err = r.col.FindOneAndUpdate(ctx, bson.M{"_id": objectID, "deleted_at": bson.M{"$ne": nil}}, update, opts).Decode(&daoItem)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// No estaba borrado: devolvemos el item activo (o 404 si no existe)
+		item, err := r.GetByID(ctx, id)
+		if err != nil {
+			return domain.Item{}, err
+		}
+		return item, domain.ErrNotDeleted
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		// Mientras estaba borrado se creó otro item activo con el mismo nombre
+		return domain.Item{}, domain.ErrAlreadyExists
+	}
+	if err != nil {
+		return domain.Item{}, fmt.Errorf("error restoring item in DB: %w", err)
+	}
+
+	return daoItem.ToDomain(), nil
+}
+
+// Purge elimina físicamente un item, esté activo o borrado lógicamente
+// ⚠️ No se puede deshacer. version > 0 hace el borrado condicional
+func (r *MongoItemsRepository) Purge(ctx context.Context, id string, version int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrInvalidID
+	}
+
+	filter := bson.M{"_id": objectID}
+	if version > 0 {
+		filter["version"] = version
+	}
+
+	result, err := r.col.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("error purging item from DB: %w", err)
+	}
+
+	// DeletedCount == 0: no existe o cambió de versión
+	if result.DeletedCount == 0 {
+		return r.missError(ctx, bson.M{"_id": objectID})
+	}
+
+	return nil
+}
+
+// CreateBatch inserta varios items con un único BulkWrite no ordenado
+// Un error en un item (ej.: nombre duplicado) no frena al resto: se reporta
+// en el BatchResult de ese índice
+func (r *MongoItemsRepository) CreateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	results := make([]domain.BatchResult, len(items))
+	if len(items) == 0 {
+		return results, nil
+	}
+
+	now := time.Now().UTC()
+	models := make([]mongo.WriteModel, len(items))
+	for i, item := range items {
+		item.CreatedAt = now
+		item.UpdatedAt = now
+		item.Version = 1
+
+		daoItem := dao.FromDomain(item)
+		daoItem.ID = primitive.NewObjectID()
+		models[i] = mongo.NewInsertOneModel().SetDocument(daoItem)
+
+		created := daoItem.ToDomain()
+		results[i] = domain.BatchResult{Index: i, ID: created.ID, Item: &created, Created: true}
+	}
+
+	// Ordered(false): Mongo intenta todos los inserts aunque alguno falle
+	indexes := make([]int, len(models))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	if err := r.bulkWrite(ctx, models, indexes, results); err != nil {
+		return abortedBatch(results, err)
+	}
+
+	return results, nil
+}
+
+// UpdateBatch actualiza varios items con un único BulkWrite no ordenado
+// Cada item debe traer ID; Version > 0 hace su update condicional
+func (r *MongoItemsRepository) UpdateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+
+	// Estado previo de cada item: fija la versión contra la que se escribe y
+	// es el Before del evento ItemUpdated
+	results, before, err := r.readBatch(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	var models []mongo.WriteModel
+	var modelResults []int // índice en results de cada model
+	for i, item := range items {
+		if results[i].Err != nil || !expectVersion(&results[i], before, item.Version) {
+			continue
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(pinnedFilter(before[results[i].ID])).
+			SetUpdate(bson.M{
+				"$set": bson.M{"name": item.Name, "price": item.Price, "updated_at": now},
+				"$inc": bson.M{"version": 1},
+			}))
+		modelResults = append(modelResults, i)
+	}
+
+	if err := r.bulkWrite(ctx, models, modelResults, results); err != nil {
+		return abortedBatch(results, err)
+	}
+
+	// El BulkWriteResult solo trae totales: releemos los documentos para saber
+	// cuáles escribió este batch (versión leída + 1) y cuáles no matchearon
+	return r.verifyBatch(ctx, results, before, func(d dao.Item) bool {
+		return d.DeletedAt == nil
+	})
+}
+
+// DeleteBatch hace el borrado lógico de varios items con un único BulkWrite
+func (r *MongoItemsRepository) DeleteBatch(ctx context.Context, refs []domain.ItemRef) ([]domain.BatchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	ids := make([]string, len(refs))
+	for i, ref := range refs {
+		ids[i] = ref.ID
+	}
+
+	// Estado previo de cada item: ItemDeleted lleva el item antes del borrado (como Delete)
+	results, before, err := r.readBatch(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	var models []mongo.WriteModel
+	var modelResults []int
+	for i, ref := range refs {
+		if results[i].Err != nil || !expectVersion(&results[i], before, ref.Version) {
+			continue
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(pinnedFilter(before[results[i].ID])).
+			SetUpdate(bson.M{
+				"$set": bson.M{"deleted_at": now, "updated_at": now},
+				"$inc": bson.M{"version": 1},
+			}))
+		modelResults = append(modelResults, i)
+	}
+
+	if err := r.bulkWrite(ctx, models, modelResults, results); err != nil {
+		return abortedBatch(results, err)
+	}
+
+	return r.verifyBatch(ctx, results, before, func(d dao.Item) bool {
+		return d.DeletedAt != nil
+	})
+}
+
+// UpsertBatch crea o actualiza varios items con un único BulkWrite (usado por el import)
+// - Con ID: actualiza ese item (si no existe se reporta ErrNotFound)
+// - Sin ID: busca un item activo con el mismo nombre; si no hay, lo crea
+func (r *MongoItemsRepository) UpsertBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	results := make([]domain.BatchResult, len(items))
+	var ids, names bson.A
+	for i, item := range items {
+		results[i] = domain.BatchResult{Index: i, ID: item.ID}
+		if item.ID == "" {
+			names = append(names, item.Name)
+			continue
+		}
+		objectID, err := primitive.ObjectIDFromHex(item.ID)
+		if err != nil {
+			results[i].Err = domain.ErrInvalidID
+			continue
+		}
+		results[i].ID = objectID.Hex()
+		ids = append(ids, objectID)
+	}
+
+	// Estado previo de los items que ya existen, por ID o por nombre
+	before, err := r.findBatch(ctx, ids, names)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]dao.Item, len(before))
+	for _, daoItem := range before {
+		if daoItem.DeletedAt == nil {
+			byName[daoItem.Name] = daoItem
+		}
+	}
+
+	now := time.Now().UTC()
+	var models []mongo.WriteModel
+	var modelResults []int
+	for i, item := range items {
+		if results[i].Err != nil {
+			continue
+		}
+		if results[i].ID == "" {
+			current, ok := byName[item.Name]
+			if !ok {
+				// Item nuevo: insert con ID propio, así se relee sin ambigüedad
+				// Si otro lo crea en paralelo, el índice único de name da ErrAlreadyExists
+				item.CreatedAt = now
+				item.UpdatedAt = now
+				item.Version = 1
+				daoItem := dao.FromDomain(item)
+				daoItem.ID = primitive.NewObjectID()
+				models = append(models, mongo.NewInsertOneModel().SetDocument(daoItem))
+				modelResults = append(modelResults, i)
+				results[i].ID = daoItem.ID.Hex()
+				results[i].Created = true
+				continue
+			}
+			results[i].ID = current.ID.Hex()
+		}
+
+		if !expectVersion(&results[i], before, 0) {
+			continue
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(pinnedFilter(before[results[i].ID])).
+			SetUpdate(bson.M{
+				"$set": bson.M{"name": item.Name, "price": item.Price, "updated_at": now},
+				"$inc": bson.M{"version": 1},
+			}))
+		modelResults = append(modelResults, i)
+	}
+
+	if err := r.bulkWrite(ctx, models, modelResults, results); err != nil {
+		return abortedBatch(results, err)
+	}
+
+	return r.verifyBatch(ctx, results, before, func(d dao.Item) bool {
+		return d.DeletedAt == nil
+	})
+}
+
+// Stream recorre todos los items activos (orden por _id) llamando a fn por cada uno
+// Usa el cursor de Mongo: los documentos se traen por lotes, nunca todos en memoria
+// Si fn retorna error se corta el recorrido y se retorna ese error
+func (r *MongoItemsRepository) Stream(ctx context.Context, fn func(domain.Item) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetBatchSize(500)
+	cur, err := r.col.Find(ctx, bson.M{"deleted_at": nil}, opts)
+	if err != nil {
+		return fmt.Errorf("error streaming items from DB: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var daoItem dao.Item
+		if err := cur.Decode(&daoItem); err != nil {
+			return fmt.Errorf("error decoding item: %w", err)
+		}
+		if err := fn(daoItem.ToDomain()); err != nil {
+			return err
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return fmt.Errorf("error streaming items from DB: %w", err)
+	}
+	return nil
+}
+
+// bulkWrite ejecuta models sin orden, donde models[i] corresponde a results[indexes[i]]
+// Los errores de escritura por item se guardan en su BatchResult; solo se
+// retorna error si falló el batch completo
+// 🔒 Dentro de una transacción el primer error de escritura la aborta entera:
+// el resto de los items se marca con domain.ErrBatchAborted y se retorna ese error
+func (r *MongoItemsRepository) bulkWrite(ctx context.Context, models []mongo.WriteModel, indexes []int, results []domain.BatchResult) error {
+	if len(models) == 0 {
+		return nil
+	}
+
+	_, err := r.col.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err == nil {
+		return nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return fmt.Errorf("error running bulk write in DB: %w", err)
+	}
+
+	for _, writeErr := range bulkErr.WriteErrors {
+		result := &results[indexes[writeErr.Index]]
+		result.Item = nil
+		result.Created = false
+		if writeErr.HasErrorCode(11000) {
+			result.Err = domain.ErrAlreadyExists
+		} else {
+			result.Err = fmt.Errorf("error writing item in DB: %w", writeErr.WriteError)
+		}
+	}
+
+	if mongo.SessionFromContext(ctx) != nil && len(bulkErr.WriteErrors) > 0 {
+		for _, i := range indexes {
+			if results[i].Err == nil {
+				results[i].Item = nil
+				results[i].Created = false
+				results[i].Err = domain.ErrBatchAborted
+			}
+		}
+		return domain.ErrBatchAborted
+	}
+	return nil
+}
+
+// abortedBatch retorna los resultados de un batch abortado por el error de un
+// item (ver bulkWrite), o el error si falló el batch completo
+func abortedBatch(results []domain.BatchResult, err error) ([]domain.BatchResult, error) {
+	if errors.Is(err, domain.ErrBatchAborted) {
+		return results, nil
+	}
+	return nil, err
+}
+
+// readBatch arma los resultados de un batch por ID y lee el estado actual de
+// cada item; los IDs inválidos quedan con ErrInvalidID
+func (r *MongoItemsRepository) readBatch(ctx context.Context, ids []string) ([]domain.BatchResult, map[string]dao.Item, error) {
+	results := make([]domain.BatchResult, len(ids))
+	var objectIDs bson.A
+	for i, id := range ids {
+		results[i] = domain.BatchResult{Index: i, ID: id}
+
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			results[i].Err = domain.ErrInvalidID
+			continue
+		}
+		// Normalizado: es la clave de findBatch
+		results[i].ID = objectID.Hex()
+		objectIDs = append(objectIDs, objectID)
+	}
+
+	before, err := r.findBatch(ctx, objectIDs, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return results, before, nil
+}
+
+// expectVersion chequea un item del batch contra su estado previo: si no existe
+// o la versión pedida (> 0) no es la actual, reporta el error sin escribirlo
+func expectVersion(result *domain.BatchResult, before map[string]dao.Item, version int64) bool {
+	current, ok := before[result.ID]
+	switch {
+	case !ok || current.DeletedAt != nil:
+		result.Err = domain.ErrNotFound
+	case version > 0 && version != current.Version:
+		result.Err = domain.ErrVersionConflict
+	default:
+		return true
+	}
+	return false
+}
+
+// pinnedFilter matchea el item solo si sigue activo y en la versión leída:
+// así la escritura del batch deja exactamente la versión siguiente
+func pinnedFilter(current dao.Item) bson.M {
+	return bson.M{"_id": current.ID, "deleted_at": nil, "version": current.Version}
+}
+
+// findBatch lee los items con esos IDs y los activos con esos nombres, indexados por ID
+func (r *MongoItemsRepository) findBatch(ctx context.Context, ids bson.A, names bson.A) (map[string]dao.Item, error) {
+	var or bson.A
+	if len(ids) > 0 {
+		or = append(or, bson.M{"_id": bson.M{"$in": ids}})
+	}
+	if len(names) > 0 {
+		or = append(or, bson.M{"name": bson.M{"$in": names}, "deleted_at": nil})
+	}
+	if len(or) == 0 {
+		return map[string]dao.Item{}, nil
+	}
+
+	cur, err := r.col.Find(ctx, bson.M{"$or": or})
+	if err != nil {
+		return nil, fmt.Errorf("error reading batch items from DB: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var daoItems []dao.Item
+	if err := cur.All(ctx, &daoItems); err != nil {
+		return nil, fmt.Errorf("error decoding batch items: %w", err)
+	}
+
+	byID := make(map[string]dao.Item, len(daoItems))
+	for _, daoItem := range daoItems {
+		byID[daoItem.ID.Hex()] = daoItem
+	}
+	return byID, nil
+}
+
+// verifyBatch relee los items sin error y decide si la escritura es de este
+// batch: los creados existen con su ID nuevo; los actualizados deben estar en
+// la versión leída + 1 y cumplir applied. Si no, reporta ErrNotFound o
+// ErrVersionConflict como en Update
+// 🔢 Se compara la versión y no updated_at: otra escritura en el mismo ms
+// dejaría la misma fecha, pero nunca la misma versión
+func (r *MongoItemsRepository) verifyBatch(ctx context.Context, results []domain.BatchResult, before map[string]dao.Item, applied func(dao.Item) bool) ([]domain.BatchResult, error) {
+	var ids bson.A
+	for _, res := range results {
+		if res.Err == nil {
+			objectID, _ := primitive.ObjectIDFromHex(res.ID)
+			ids = append(ids, objectID)
+		}
+	}
+	byID, err := r.findBatch(ctx, ids, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+		daoItem, ok := byID[results[i].ID]
+		current, existed := before[results[i].ID]
+		switch {
+		case ok && results[i].Created:
+			item := daoItem.ToDomain()
+			results[i].Item = &item
+		case ok && existed && daoItem.Version == current.Version+1 && applied(daoItem):
+			item := daoItem.ToDomain()
+			prev := current.ToDomain()
+			results[i].Item = &item
+			results[i].Before = &prev
+		case !ok || daoItem.DeletedAt != nil:
+			results[i].Err = domain.ErrNotFound
+		default:
+			results[i].Err = domain.ErrVersionConflict
+		}
+	}
+	return results, nil
+}
+
+// Search busca items activos por texto, ordenados por relevancia
+// Usa el índice de texto ($text + textScore); si no existe, cae a una
+// búsqueda por regex case-insensitive rankeada por cantidad de términos encontrados
+func (r *MongoItemsRepository) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchHit, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	hits, err := r.textSearch(ctx, query)
+	if err == nil {
+		return hits, nil
+	}
+
+	// IndexNotFound (27): no hay índice de texto, usamos regex
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) && serverErr.HasErrorCode(27) {
+		return r.regexSearch(ctx, query)
+	}
+	return nil, fmt.Errorf("error searching items in DB: %w", err)
+}
+
+// scoredItem es un dao.Item con el score calculado por $text
+type scoredItem struct {
+	dao.Item `bson:",inline"`
+	Score    float64 `bson:"score"`
+}
+
+// textSearch usa el operador $text y ordena por textScore
+func (r *MongoItemsRepository) textSearch(ctx context.Context, query domain.SearchQuery) ([]domain.SearchHit, error) {
+	filter := bson.M{"$text": bson.M{"$search": query.Text}, "deleted_at": nil}
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}}).
+		SetLimit(int64(query.Limit))
+
+	cur, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var results []scoredItem
+	if err := cur.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	hits := make([]domain.SearchHit, len(results))
+	for i, res := range results {
+		hits[i] = domain.SearchHit{Item: res.Item.ToDomain(), Score: res.Score}
+	}
+	return hits, nil
+}
+
+// regexSearch busca cualquiera de los términos en el nombre (sin distinguir mayúsculas)
+// El score es la proporción de términos de la búsqueda que aparecen en el nombre
+func (r *MongoItemsRepository) regexSearch(ctx context.Context, query domain.SearchQuery) ([]domain.SearchHit, error) {
+	terms := strings.Fields(query.Text)
+	or := make(bson.A, len(terms))
+	patterns := make([]*regexp.Regexp, len(terms))
+	for i, term := range terms {
+		or[i] = bson.M{"name": bson.M{"$regex": regexp.QuoteMeta(term), "$options": "i"}}
+		patterns[i] = regexp.MustCompile("(?i)" + regexp.QuoteMeta(term))
+	}
+
+	// Sin índice no hay ranking en DB: traemos un tope y ordenamos en memoria
+	opts := options.Find().SetLimit(int64(query.Limit) * 10)
+	cur, err := r.col.Find(ctx, bson.M{"$or": or, "deleted_at": nil}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error searching items in DB: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	var daoItems []dao.Item
+	if err := cur.All(ctx, &daoItems); err != nil {
+		return nil, fmt.Errorf("error decoding search results: %w", err)
+	}
+
+	hits := make([]domain.SearchHit, len(daoItems))
+	for i, daoItem := range daoItems {
+		matched := 0
+		for _, pattern := range patterns {
+			if pattern.MatchString(daoItem.Name) {
+				matched++
+			}
+		}
+		hits[i] = domain.SearchHit{Item: daoItem.ToDomain(), Score: float64(matched) / float64(len(patterns))}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+	return hits, nil
+}
+
+// activeFilter arma el filtro por ID de un item no borrado,
+// agregando la versión esperada si viene
+func activeFilter(objectID primitive.ObjectID, version int64) bson.M {
+	filter := bson.M{"_id": objectID, "deleted_at": nil}
+	if version > 0 {
+		filter["version"] = version
+	}
+	return filter
+}
+
+// missError explica por qué una escritura condicional no encontró documento:
+// si el item existe (según existsFilter) es porque cambió de versión, si no es un 404
+func (r *MongoItemsRepository) missError(ctx context.Context, existsFilter bson.M) error {
+	count, err := r.col.CountDocuments(ctx, existsFilter)
+	if err != nil {
+		return fmt.Errorf("error checking item in DB: %w", err)
+	}
+	if count > 0 {
+		return domain.ErrVersionConflict
+	}
+	return domain.ErrNotFound
 }
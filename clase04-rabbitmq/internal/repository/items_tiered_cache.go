@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ItemsCacheTier es un nivel de la cache escalonada (CCache, Memcached, ...)
+// Cada nivel tiene su propio TTL, configurado en su constructor
+type ItemsCacheTier interface {
+	Create(ctx context.Context, item domain.Item) (domain.Item, error)
+	GetByID(ctx context.Context, id string) (domain.Item, error)
+	Update(ctx context.Context, id string, item domain.Item) (domain.Item, error)
+	Delete(ctx context.Context, id string, version int64) error
+	CreateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error)
+	ListKey(ctx context.Context, query domain.ItemsQuery) (string, error)
+	GetList(ctx context.Context, key string) (domain.ItemsPage, error)
+	SetList(ctx context.Context, key string, page domain.ItemsPage) error
+	InvalidateLists(ctx context.Context) error
+}
+
+// tierKeySeparator separa la clave de cada nivel en las claves de listados
+// (cada nivel tiene su propia generación)
+const tierKeySeparator = "|"
+
+// TieredItemsRepository encadena varias caches: la primera es la más rápida
+// (L1, en memoria) y las siguientes las compartidas (L2, Memcached)
+// Si ningún nivel tiene el item se retorna domain.ErrCacheMiss y el service va a Mongo
+type TieredItemsRepository struct {
+	tiers []ItemsCacheTier
+}
+
+// NewTieredItemsRepository crea la cache con los niveles en orden (L1 primero)
+func NewTieredItemsRepository(tiers ...ItemsCacheTier) TieredItemsRepository {
+	return TieredItemsRepository{tiers: tiers}
+}
+
+// List retorna la página cacheada para el query (domain.ErrCacheMiss si no está)
+func (r TieredItemsRepository) List(ctx context.Context, query domain.ItemsQuery) (domain.ItemsPage, error) {
+	key, err := r.ListKey(ctx, query)
+	if err != nil {
+		return domain.ItemsPage{}, err
+	}
+	return r.GetList(ctx, key)
+}
+
+// ListKey junta las claves del query en cada nivel
+func (r TieredItemsRepository) ListKey(ctx context.Context, query domain.ItemsQuery) (string, error) {
+	keys := make([]string, len(r.tiers))
+	for i, tier := range r.tiers {
+		key, err := tier.ListKey(ctx, query)
+		if err != nil {
+			return "", fmt.Errorf("L%d: %w", i+1, err)
+		}
+		keys[i] = key
+	}
+	return strings.Join(keys, tierKeySeparator), nil
+}
+
+// GetList busca la página nivel por nivel y la promueve a los superiores (ver GetByID)
+func (r TieredItemsRepository) GetList(ctx context.Context, key string) (domain.ItemsPage, error) {
+	keys, err := r.splitListKey(key)
+	if err != nil {
+		return domain.ItemsPage{}, err
+	}
+
+	var errs []error
+	for i, tier := range r.tiers {
+		page, err := tier.GetList(ctx, keys[i])
+		if errors.Is(err, domain.ErrCacheMiss) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("L%d: %w", i+1, err))
+			continue
+		}
+
+		for j, upper := range r.tiers[:i] {
+			_ = upper.SetList(ctx, keys[j], page)
+		}
+		return page, nil
+	}
+
+	if len(errs) > 0 {
+		return domain.ItemsPage{}, errors.Join(errs...)
+	}
+	return domain.ItemsPage{}, domain.ErrCacheMiss
+}
+
+// SetList guarda la página en todos los niveles
+func (r TieredItemsRepository) SetList(ctx context.Context, key string, page domain.ItemsPage) error {
+	keys, err := r.splitListKey(key)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, tier := range r.tiers {
+		if err := tier.SetList(ctx, keys[i], page); err != nil {
+			errs = append(errs, fmt.Errorf("L%d: %w", i+1, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// InvalidateLists invalida los listados de todos los niveles (de abajo hacia arriba, ver Update)
+func (r TieredItemsRepository) InvalidateLists(ctx context.Context) error {
+	return r.eachTierReversed(func(tier ItemsCacheTier) error {
+		return tier.InvalidateLists(ctx)
+	})
+}
+
+// Create guarda el item en todos los niveles
+func (r TieredItemsRepository) Create(ctx context.Context, item domain.Item) (domain.Item, error) {
+	var errs []error
+	for i, tier := range r.tiers {
+		if _, err := tier.Create(ctx, item); err != nil {
+			errs = append(errs, fmt.Errorf("L%d: %w", i+1, err))
+		}
+	}
+	return item, errors.Join(errs...)
+}
+
+// GetByID busca el item nivel por nivel y, si lo encuentra en uno inferior,
+// lo promueve a los niveles superiores (cada uno con su TTL)
+// Un nivel caído se saltea: se sigue con el siguiente. Un item vencido
+// (domain.ErrCacheStale) sólo se retorna si ningún nivel inferior lo tiene fresco
+func (r TieredItemsRepository) GetByID(ctx context.Context, id string) (domain.Item, error) {
+	var errs []error
+	var stale *domain.Item
+	for i, tier := range r.tiers {
+		item, err := tier.GetByID(ctx, id)
+		if errors.Is(err, domain.ErrCacheMiss) {
+			continue
+		}
+		if errors.Is(err, domain.ErrCacheStale) {
+			if stale == nil {
+				stale = &item
+			}
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("L%d: %w", i+1, err))
+			continue
+		}
+
+		// ⬆️ Promoción: la próxima lectura sale del nivel más rápido
+		for _, upper := range r.tiers[:i] {
+			_, _ = upper.Create(ctx, item)
+		}
+		return item, nil
+	}
+
+	if stale != nil {
+		return *stale, domain.ErrCacheStale
+	}
+	if len(errs) > 0 {
+		return domain.Item{}, errors.Join(errs...)
+	}
+	return domain.Item{}, domain.ErrCacheMiss
+}
+
+// Update reemplaza el item en los niveles donde esté cacheado
+// Se recorre de abajo hacia arriba: así una lectura concurrente no vuelve a
+// promover a L1 la versión vieja que todavía estaba en L2
+func (r TieredItemsRepository) Update(ctx context.Context, id string, item domain.Item) (domain.Item, error) {
+	return item, r.eachTierReversed(func(tier ItemsCacheTier) error {
+		_, err := tier.Update(ctx, id, item)
+		return err
+	})
+}
+
+// Delete invalida el item en todos los niveles (de abajo hacia arriba, ver Update)
+func (r TieredItemsRepository) Delete(ctx context.Context, id string, version int64) error {
+	return r.eachTierReversed(func(tier ItemsCacheTier) error {
+		return tier.Delete(ctx, id, version)
+	})
+}
+
+func (r TieredItemsRepository) Restore(ctx context.Context, id string) (domain.Item, error) {
+	return domain.Item{}, fmt.Errorf("%w: restore in tiered cache", domain.ErrNotSupported)
+}
+
+func (r TieredItemsRepository) Purge(ctx context.Context, id string, version int64) error {
+	return fmt.Errorf("%w: purge in tiered cache", domain.ErrNotSupported)
+}
+
+// Search no está soportado: la cache no puede rankear por relevancia
+func (r TieredItemsRepository) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchHit, error) {
+	return nil, fmt.Errorf("%w: search in tiered cache", domain.ErrNotSupported)
+}
+
+// CreateBatch guarda los items en todos los niveles
+// El resultado de cada item refleja el primer error que tuvo en algún nivel
+func (r TieredItemsRepository) CreateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	results := make([]domain.BatchResult, len(items))
+	for i, item := range items {
+		cached := item
+		results[i] = domain.BatchResult{Index: i, ID: item.ID, Item: &cached}
+	}
+
+	for t, tier := range r.tiers {
+		tierResults, err := tier.CreateBatch(ctx, items)
+		if err != nil {
+			return nil, fmt.Errorf("L%d: %w", t+1, err)
+		}
+		for i, res := range tierResults {
+			if res.Err != nil && results[i].Err == nil {
+				results[i].Err = fmt.Errorf("L%d: %w", t+1, res.Err)
+				results[i].Item = nil
+			}
+		}
+	}
+	return results, nil
+}
+
+func (r TieredItemsRepository) UpdateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	return nil, fmt.Errorf("%w: batch update in tiered cache", domain.ErrNotSupported)
+}
+
+func (r TieredItemsRepository) DeleteBatch(ctx context.Context, refs []domain.ItemRef) ([]domain.BatchResult, error) {
+	return nil, fmt.Errorf("%w: batch delete in tiered cache", domain.ErrNotSupported)
+}
+
+func (r TieredItemsRepository) UpsertBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	return nil, fmt.Errorf("%w: batch upsert in tiered cache", domain.ErrNotSupported)
+}
+
+func (r TieredItemsRepository) Stream(ctx context.Context, fn func(domain.Item) error) error {
+	return fmt.Errorf("%w: stream in tiered cache", domain.ErrNotSupported)
+}
+
+// splitListKey separa una clave de ListKey en las claves de cada nivel
+func (r TieredItemsRepository) splitListKey(key string) ([]string, error) {
+	keys := strings.Split(key, tierKeySeparator)
+	if len(keys) != len(r.tiers) {
+		return nil, fmt.Errorf("invalid tiered list key %q", key)
+	}
+	return keys, nil
+}
+
+// eachTierReversed aplica fn desde el último nivel hasta L1
+// Retorna domain.ErrCacheMiss sólo si ningún nivel tenía el item
+func (r TieredItemsRepository) eachTierReversed(fn func(tier ItemsCacheTier) error) error {
+	var errs []error
+	misses := 0
+	for i := len(r.tiers) - 1; i >= 0; i-- {
+		err := fn(r.tiers[i])
+		if errors.Is(err, domain.ErrCacheMiss) {
+			misses++
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("L%d: %w", i+1, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if misses == len(r.tiers) {
+		return domain.ErrCacheMiss
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"clase04-rabbitmq/internal/dao"
+	"clase04-rabbitmq/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// sentEventsRetention es cuánto se guardan los eventos ya publicados
+const sentEventsRetention = 7 * 24 * time.Hour
+
+// MongoOutboxRepository guarda los eventos de items en una colección "outbox"
+// Implementa ItemsPublisher: Publish no envía a RabbitMQ, registra el evento
+// para que el relay lo publique después
+type MongoOutboxRepository struct {
+	col *mongo.Collection
+}
+
+// NewMongoOutboxRepository crea el repository sobre la colección indicada
+// Usar la misma base (y cliente) que los items para poder escribir ambos en una transacción
+func NewMongoOutboxRepository(ctx context.Context, db *mongo.Database, collectionName string) *MongoOutboxRepository {
+	repo := &MongoOutboxRepository{col: db.Collection(collectionName)}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := repo.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// Pendientes por fecha de próximo intento (consulta del relay)
+			Keys:    bson.D{{Key: "sent_at", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+			Options: options.Index().SetName("pending"),
+		},
+		{
+			// TTL: Mongo borra solo los eventos ya enviados
+			Keys:    bson.D{{Key: "sent_at", Value: 1}},
+			Options: options.Index().SetName("sent_ttl").SetExpireAfterSeconds(int32(sentEventsRetention.Seconds())),
+		},
+	})
+	if err != nil {
+		log.Printf("⚠️ could not create outbox indexes: %v", err)
+	}
+
+	return repo
+}
+
+// Publish registra un evento pendiente en el outbox
+// Si ctx viene de WithTransaction, se guarda en la misma transacción que el item
+func (r *MongoOutboxRepository) Publish(ctx context.Context, event domain.CloudEvent) error {
+	row, err := dao.OutboxEventFromDomain(event, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if _, err := r.col.InsertOne(ctx, row); err != nil {
+		return fmt.Errorf("error inserting event in outbox: %w", err)
+	}
+	return nil
+}
+
+// ClaimPending toma hasta limit eventos pendientes para publicarlos
+// Cada evento tomado queda reservado durante lease (se corre next_attempt_at),
+// así otra réplica del relay no lo publica al mismo tiempo. Si el relay se cae
+// antes de marcarlo como enviado, vuelve a estar disponible al vencer el lease
+func (r *MongoOutboxRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var events []domain.OutboxEvent
+	for len(events) < limit {
+		now := time.Now().UTC()
+		filter := bson.M{"sent_at": nil, "next_attempt_at": bson.M{"$lte": now}}
+		update := bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}}
+		opts := options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "_id", Value: 1}}). // Más viejos primero
+			SetReturnDocument(options.After)
+
+		var event dao.OutboxEvent
+		err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&event)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			return events, fmt.Errorf("error claiming outbox event: %w", err)
+		}
+		claimed, decodeErr := event.ToDomain()
+		if decodeErr != nil {
+			// ☠️ Una fila ilegible nunca se va a poder publicar: la sacamos de la cola
+			log.Printf("outbox: skipping event %s: %v", event.ID.Hex(), decodeErr)
+			if _, err := r.col.UpdateOne(ctx, bson.M{"_id": event.ID}, bson.M{
+				"$set": bson.M{"sent_at": now, "last_error": decodeErr.Error()},
+			}); err != nil {
+				return events, fmt.Errorf("error discarding outbox event: %w", err)
+			}
+			continue
+		}
+		events = append(events, claimed)
+	}
+	return events, nil
+}
+
+// MarkSent marca un evento como publicado
+func (r *MongoOutboxRepository) MarkSent(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrInvalidID
+	}
+
+	if _, err := r.col.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$set": bson.M{"sent_at": time.Now().UTC()},
+	}); err != nil {
+		return fmt.Errorf("error marking outbox event as sent: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed registra un intento fallido y reprograma el evento para retryAt
+func (r *MongoOutboxRepository) MarkFailed(ctx context.Context, id string, cause error, retryAt time.Time) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrInvalidID
+	}
+
+	if _, err := r.col.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$set": bson.M{"next_attempt_at": retryAt.UTC(), "last_error": cause.Error()},
+		"$inc": bson.M{"attempts": 1},
+	}); err != nil {
+		return fmt.Errorf("error marking outbox event as failed: %w", err)
+	}
+	return nil
+}
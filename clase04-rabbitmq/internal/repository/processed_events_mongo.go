@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoProcessedEventsRepository registra los eventos que ya procesó un consumidor
+// Implementa worker.DedupeStore. Cada consumidor (cola) tiene su propio registro:
+// el mismo evento lo procesan una vez cada uno
+type MongoProcessedEventsRepository struct {
+	col      *mongo.Collection
+	consumer string
+}
+
+// NewMongoProcessedEventsRepository crea el registro del consumidor indicado
+// Los eventos procesados se borran solos después de retention (índice TTL):
+// tiene que superar el tiempo máximo en que un duplicado puede llegar
+func NewMongoProcessedEventsRepository(ctx context.Context, db *mongo.Database, collectionName string, consumer string, retention time.Duration) *MongoProcessedEventsRepository {
+	repo := &MongoProcessedEventsRepository{col: db.Collection(collectionName), consumer: consumer}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Los reclamos que nunca se terminaron (sin processed_at) vencen por claimed_at
+	_, err := repo.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "processed_at", Value: 1}},
+			Options: options.Index().SetName("processed_ttl").SetExpireAfterSeconds(int32(retention.Seconds())),
+		},
+		{
+			Keys:    bson.D{{Key: "claimed_at", Value: 1}},
+			Options: options.Index().SetName("claimed_ttl").SetExpireAfterSeconds(int32(retention.Seconds())),
+		},
+	})
+	if err != nil {
+		log.Printf("⚠️ could not create processed events TTL indexes: %v", err)
+	}
+
+	return repo
+}
+
+// claimTimeout es cuánto dura el reclamo de un evento sin terminar de procesarse
+// Pasado ese tiempo se asume que el worker que lo tomó se cayó y otro lo puede tomar
+const claimTimeout = 5 * time.Minute
+
+// Claim reclama el evento para procesarlo: el insert sobre el _id único es
+// atómico, así dos workers con el mismo evento nunca lo procesan a la vez
+// Retorna false si ya fue procesado y error si otro worker lo está procesando
+func (r *MongoProcessedEventsRepository) Claim(ctx context.Context, eventID string) (bool, error) {
+	now := time.Now().UTC()
+	_, err := r.col.InsertOne(ctx, bson.M{
+		"_id":        r.key(eventID),
+		"consumer":   r.consumer,
+		"event_id":   eventID,
+		"claimed_at": now,
+	})
+	if err == nil {
+		return true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return false, fmt.Errorf("error claiming event: %w", err)
+	}
+
+	// Ya hay un registro: si es un reclamo vencido (sin processed_at) lo tomamos
+	res, err := r.col.UpdateOne(ctx, bson.M{
+		"_id":          r.key(eventID),
+		"processed_at": bson.M{"$exists": false},
+		"claimed_at":   bson.M{"$lt": now.Add(-claimTimeout)},
+	}, bson.M{"$set": bson.M{"claimed_at": now}})
+	if err != nil {
+		return false, fmt.Errorf("error claiming event: %w", err)
+	}
+	if res.ModifiedCount > 0 {
+		return true, nil
+	}
+
+	processed, err := r.col.CountDocuments(ctx, bson.M{
+		"_id":          r.key(eventID),
+		"processed_at": bson.M{"$exists": true},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("error checking processed event: %w", err)
+	}
+	if processed == 0 {
+		return false, fmt.Errorf("event %s is being processed by another worker", eventID)
+	}
+	return false, nil
+}
+
+// Release libera el reclamo de un evento que no se pudo procesar (se va a reintentar)
+func (r *MongoProcessedEventsRepository) Release(ctx context.Context, eventID string) error {
+	_, err := r.col.DeleteOne(ctx, bson.M{
+		"_id":          r.key(eventID),
+		"processed_at": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return fmt.Errorf("error releasing event: %w", err)
+	}
+	return nil
+}
+
+// MarkProcessed registra el evento reclamado como procesado
+func (r *MongoProcessedEventsRepository) MarkProcessed(ctx context.Context, eventID string) error {
+	// Upsert: si el reclamo ya no existe (ej.: se vació la colección) se crea igual
+	_, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": r.key(eventID)},
+		bson.M{
+			"$set": bson.M{"processed_at": time.Now().UTC()},
+			"$setOnInsert": bson.M{
+				"consumer": r.consumer,
+				"event_id": eventID,
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("error marking event as processed: %w", err)
+	}
+	return nil
+}
+
+// key arma el _id del registro: "<consumidor>:<evento>"
+func (r *MongoProcessedEventsRepository) key(eventID string) string {
+	return r.consumer + ":" + eventID
+}
@@ -1,77 +1,189 @@
 package services
 
 import (
+	"clase04-rabbitmq/internal/correlation"
 	"clase04-rabbitmq/internal/domain"
 	"context"
 	"errors"
 	"fmt"
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+	"html"
+	"log"
+	"regexp"
 	"strings"
+	"time"
 )
 
 // ItemsRepository define las operaciones de datos para Items
 // Patrón Repository: abstrae el acceso a datos del resto de la aplicación
 type ItemsRepository interface {
-	// List retorna todos los items de la base de datos
-	List(ctx context.Context) ([]domain.Item, error)
+	// List retorna una página de items que cumplen el query
+	// En las caches: la página cacheada para el query o domain.ErrCacheMiss
+	List(ctx context.Context, query domain.ItemsQuery) (domain.ItemsPage, error)
 
 	// Create inserta un nuevo item en DB
 	Create(ctx context.Context, item domain.Item) (domain.Item, error)
 
 	// GetByID busca un item por su ID
+	// En las caches: domain.ErrCacheMiss si no está y domain.ErrCacheStale
+	// (junto con el item) si venció pero se puede servir mientras se refresca
 	GetByID(ctx context.Context, id string) (domain.Item, error)
 
 	// Update actualiza un item existente
+	// Si item.Version > 0 solo actualiza si coincide con la versión guardada
+	// En las caches solo reemplaza una entrada existente (domain.ErrCacheMiss si no está)
 	Update(ctx context.Context, id string, item domain.Item) (domain.Item, error)
 
-	// Delete elimina un item por ID
-	Delete(ctx context.Context, id string) error
+	// Delete elimina un item por ID (borrado lógico en DB)
+	// Si version > 0 solo elimina si coincide con la versión guardada
+	// En las caches borra la entrada (domain.ErrCacheMiss si no estaba)
+	Delete(ctx context.Context, id string, version int64) error
+
+	// Restore recupera un item borrado lógicamente (domain.ErrNotDeleted si estaba activo)
+	Restore(ctx context.Context, id string) (domain.Item, error)
+
+	// Purge elimina un item definitivamente
+	Purge(ctx context.Context, id string, version int64) error
+
+	// Search busca items por texto ordenados por relevancia
+	// Las caches retornan domain.ErrNotSupported
+	Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchHit, error)
+
+	// CreateBatch inserta varios items, reportando el resultado de cada uno
+	CreateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error)
+
+	// UpdateBatch actualiza varios items (cada uno con su ID y versión esperada)
+	// Las caches retornan domain.ErrNotSupported
+	UpdateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error)
+
+	// DeleteBatch borra lógicamente varios items
+	// Las caches retornan domain.ErrNotSupported
+	DeleteBatch(ctx context.Context, refs []domain.ItemRef) ([]domain.BatchResult, error)
+
+	// UpsertBatch crea o actualiza varios items (por ID, o por nombre si no traen ID)
+	// Las caches retornan domain.ErrNotSupported
+	UpsertBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error)
+
+	// Stream recorre todos los items activos sin cargarlos todos en memoria
+	// Las caches retornan domain.ErrNotSupported
+	Stream(ctx context.Context, fn func(domain.Item) error) error
 } // ItemsServiceImpl implementa ItemsService
 
+// ItemsCache es la cache de items: además de las operaciones de ItemsRepository
+// guarda páginas de List por query. Los listados se invalidan todos juntos
+// con cada escritura (no se puede saber qué páginas incluían al item)
+type ItemsCache interface {
+	ItemsRepository
+
+	// ListKey arma la clave del query en la generación actual de listados
+	// Se pide antes de ir a DB: si una escritura la invalida mientras tanto,
+	// la página se guarda en una generación que ya nadie lee
+	ListKey(ctx context.Context, query domain.ItemsQuery) (string, error)
+
+	// GetList retorna la página guardada bajo key o domain.ErrCacheMiss
+	GetList(ctx context.Context, key string) (domain.ItemsPage, error)
+
+	// SetList guarda la página bajo key
+	SetList(ctx context.Context, key string, page domain.ItemsPage) error
+
+	// InvalidateLists descarta todos los listados cacheados (nueva generación)
+	InvalidateLists(ctx context.Context) error
+}
+
+// ItemsPublisher comunica las novedades de escritura de items
+// En la API es el outbox: el evento se guarda junto con el item y un relay
+// lo publica en RabbitMQ después
 type ItemsPublisher interface {
-	Publish(ctx context.Context, action string, itemID string) error
+	Publish(ctx context.Context, event domain.CloudEvent) error
+}
+
+// Transactor ejecuta fn dentro de una transacción de DB
+// Lo que se escriba usando el ctx recibido por fn se confirma todo junto o nada
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type ItemsServiceImpl struct {
 	repository ItemsRepository // Inyección de dependencia
-	cache      ItemsRepository // Inyección de dependencia
+	cache      ItemsCache      // Inyección de dependencia
 	publisher  ItemsPublisher
+	tx         Transactor
+	loads      *singleflight.Group // Lecturas a DB en curso por ID (ver load)
 }
 
 // NewItemsService crea una nueva instancia del service
 // Pattern: Dependency Injection - recibe dependencies como parámetros
-func NewItemsService(repository ItemsRepository, cache ItemsRepository, publisher ItemsPublisher) ItemsServiceImpl {
+func NewItemsService(repository ItemsRepository, cache ItemsCache, publisher ItemsPublisher, tx Transactor) ItemsServiceImpl {
 	return ItemsServiceImpl{
 		repository: repository,
 		cache:      cache,
 		publisher:  publisher,
+		tx:         tx,
+		loads:      &singleflight.Group{},
 	}
 }
 
-// List obtiene todos los items
-// ✅ IMPLEMENTADO - Delegación simple al repository
-func (s *ItemsServiceImpl) List(ctx context.Context) ([]domain.Item, error) {
-	// En este caso, no hay lógica de negocio especial
-	// Solo delegamos al repository
-	return s.repository.List(ctx)
+// List obtiene una página de items
+// Valida/normaliza el query y lo busca en cache; si no está delega al repository
+func (s *ItemsServiceImpl) List(ctx context.Context, query domain.ItemsQuery) (domain.ItemsPage, error) {
+	query, err := s.validateQuery(query)
+	if err != nil {
+		return domain.ItemsPage{}, err
+	}
+
+	// 📚 La clave sale del query normalizado: mismo listado, misma clave
+	key, err := s.cache.ListKey(ctx, query)
+	if err != nil {
+		s.logCacheError("list key", "", err)
+	} else if page, err := s.cache.GetList(ctx, key); err == nil {
+		return page, nil
+	} else {
+		s.logCacheError("get list", key, err)
+	}
+
+	page, err := s.repository.List(ctx, query)
+	if err != nil {
+		return domain.ItemsPage{}, fmt.Errorf("error listing items from repository: %w", err)
+	}
+
+	if key != "" {
+		s.logCacheError("set list", key, s.cache.SetList(ctx, key, page))
+	}
+
+	return page, nil
 }
 
 // Create valida y crea un nuevo item
 // Consigna 1: Validar name no vacío y price >= 0
 func (s *ItemsServiceImpl) Create(ctx context.Context, item domain.Item) (domain.Item, error) {
-	created, err := s.repository.Create(ctx, item)
-	if err != nil {
-		return domain.Item{}, fmt.Errorf("error creating item in repository: %w", err)
+	if err := s.validateItem(item); err != nil {
+		return domain.Item{}, err
 	}
 
-	if err := s.publisher.Publish(ctx, "create", created.ID); err != nil {
-		return domain.Item{}, fmt.Errorf("error publishing item creation: %w", err)
-	}
+	// 🔒 Item + evento en la misma transacción: o se guardan los dos o ninguno
+	var created domain.Item
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = s.repository.Create(ctx, item)
+		if err != nil {
+			return fmt.Errorf("error creating item in repository: %w", err)
+		}
 
-	_, err = s.cache.Create(ctx, created)
+		if err := s.publish(ctx, domain.ItemCreated{Item: created}); err != nil {
+			return fmt.Errorf("error publishing item creation: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return domain.Item{}, fmt.Errorf("error creating item in cache: %w", err)
+		return domain.Item{}, err
 	}
 
+	// La cache se actualiza recién después del commit
+	_, err = s.cache.Create(ctx, created)
+	s.logCacheError("create", created.ID, err)
+	s.invalidateLists(ctx)
+
 	return created, nil
 }
 
@@ -79,40 +191,576 @@ func (s *ItemsServiceImpl) Create(ctx context.Context, item domain.Item) (domain
 // Consigna 2: Validar formato de ID antes de consultar DB
 func (s *ItemsServiceImpl) GetByID(ctx context.Context, id string) (domain.Item, error) {
 	item, err := s.cache.GetByID(ctx, id)
-	if err != nil {
-		item, err := s.repository.GetByID(ctx, id)
-		if err != nil {
-			return domain.Item{}, fmt.Errorf("error getting item from repository: %w", err)
-		}
-
-		_, err = s.cache.Create(ctx, item)
-		if err != nil {
-			return domain.Item{}, fmt.Errorf("error creating item in cache: %w", err)
-		}
+	switch {
+	case err == nil:
+	case errors.Is(err, domain.ErrCacheStale):
+		// ♻️ Stale-while-revalidate: respondemos con lo cacheado y refrescamos aparte
+		go s.revalidate(context.WithoutCancel(ctx), id)
+	default:
+		// Miss o cache caída: vamos a DB igual
+		s.logCacheError("get", id, err)
+		return s.load(ctx, id)
+	}
 
-		return item, nil
+	if item.IsDeleted() {
+		// 🪦 Tombstone en cache: el item fue borrado (ver worker.CacheInvalidator)
+		// o no existe (ver load)
+		return domain.Item{}, domain.ErrNotFound
 	}
 	return item, nil
 }
 
 // Update actualiza un item existente
 // Consigna 3: Validar campos antes de actualizar
+// item.Version es la versión esperada (0 = sin precondición)
 func (s *ItemsServiceImpl) Update(ctx context.Context, id string, item domain.Item) (domain.Item, error) {
+	if err := s.validateItem(item); err != nil {
+		return domain.Item{}, err
+	}
 
-	// TODO: Actualizar en DB
-	// TODO: Guardar en cache
+	var updated domain.Item
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		// 📸 Estado previo para el evento (before/after)
+		before, err := s.repository.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("error updating item in repository: %w", err)
+		}
+
+		updated, err = s.repository.Update(ctx, id, item)
+		if err != nil {
+			return fmt.Errorf("error updating item in repository: %w", err)
+		}
+
+		if err := s.publish(ctx, domain.ItemUpdated{Before: &before, After: updated}); err != nil {
+			return fmt.Errorf("error publishing item update: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Item{}, err
+	}
 
-	return domain.Item{}, errors.New("TODO: implementar Update")
+	// Reemplazamos la entrada de cache (si no estaba cacheado no hace nada)
+	_, err = s.cache.Update(ctx, id, updated)
+	s.logCacheError("update", id, err)
+	s.invalidateLists(ctx)
+
+	return updated, nil
 }
 
 // Delete elimina un item por ID
 // Consigna 4: Validar ID antes de eliminar
-func (s *ItemsServiceImpl) Delete(ctx context.Context, id string) error {
+// version es la versión esperada (0 = sin precondición)
+func (s *ItemsServiceImpl) Delete(ctx context.Context, id string, version int64) error {
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		// 📸 El evento lleva el último estado del item
+		deleted, err := s.repository.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("error deleting item from repository: %w", err)
+		}
+
+		if err := s.repository.Delete(ctx, id, version); err != nil {
+			return fmt.Errorf("error deleting item from repository: %w", err)
+		}
+
+		if err := s.publish(ctx, domain.ItemDeleted{Item: deleted}); err != nil {
+			return fmt.Errorf("error publishing item deletion: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 🧹 Invalidamos la entrada de cache para que GetByID vaya a DB (y responda 404)
+	s.logCacheError("delete", id, s.cache.Delete(ctx, id, version))
+	s.invalidateLists(ctx)
+
+	return nil
+}
+
+// Restore recupera un item borrado lógicamente y lo vuelve a cachear
+func (s *ItemsServiceImpl) Restore(ctx context.Context, id string) (domain.Item, error) {
+	var restored domain.Item
+	notDeleted := false
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		restored, err = s.repository.Restore(ctx, id)
+		if errors.Is(err, domain.ErrNotDeleted) {
+			// Ya estaba activo: no cambió nada, no hay evento que publicar
+			notDeleted = true
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error restoring item in repository: %w", err)
+		}
+
+		if err := s.publish(ctx, domain.ItemRestored{Item: restored}); err != nil {
+			return fmt.Errorf("error publishing item restore: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Item{}, err
+	}
+	if notDeleted {
+		return restored, nil
+	}
+
+	// El item vuelve a la cache (reemplaza un tombstone si lo hubiera)
+	_, err = s.cache.Create(ctx, restored)
+	s.logCacheError("create", id, err)
+	s.invalidateLists(ctx)
+
+	return restored, nil
+}
+
+// Purge elimina un item definitivamente (activo o borrado lógicamente)
+func (s *ItemsServiceImpl) Purge(ctx context.Context, id string, version int64) error {
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repository.Purge(ctx, id, version); err != nil {
+			return fmt.Errorf("error purging item from repository: %w", err)
+		}
+
+		if err := s.publish(ctx, domain.ItemPurged{ID: id}); err != nil {
+			return fmt.Errorf("error publishing item purge: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logCacheError("delete", id, s.cache.Delete(ctx, id, version))
+	s.invalidateLists(ctx)
+
+	return nil
+}
+
+// Search busca items por texto y resalta los términos encontrados
+// Siempre va al repository: la cache no sabe rankear resultados
+func (s *ItemsServiceImpl) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchHit, error) {
+	query.Text = strings.TrimSpace(query.Text)
+	if query.Text == "" {
+		return nil, fmt.Errorf("%w: search text is required", domain.ErrInvalidQuery)
+	}
+	if query.Limit <= 0 {
+		query.Limit = domain.DefaultPageLimit
+	}
+	if query.Limit > domain.MaxPageLimit {
+		query.Limit = domain.MaxPageLimit
+	}
+
+	hits, err := s.repository.Search(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error searching items in repository: %w", err)
+	}
+
+	// ✨ Highlighting: marcamos los términos buscados en cada campo de texto
+	terms := strings.Fields(query.Text)
+	for i := range hits {
+		hits[i].Highlights = map[string]string{
+			"name": highlight(hits[i].Item.Name, terms),
+		}
+	}
+
+	return hits, nil
+}
+
+// highlight envuelve con <em></em> cada aparición (case-insensitive) de los términos
+// El texto se escapa como HTML para que el cliente pueda renderizarlo directo
+func highlight(text string, terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	pattern := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range pattern.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:loc[0]]))
+		b.WriteString("<em>" + html.EscapeString(text[loc[0]:loc[1]]) + "</em>")
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
+
+// CreateBatch valida y crea varios items en una sola escritura a DB
+// Los items inválidos se reportan en su resultado y no frenan al resto
+func (s *ItemsServiceImpl) CreateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	if err := validateBatchSize(len(items)); err != nil {
+		return nil, err
+	}
+
+	results, indexes := s.validateBatch(items)
+
+	err := s.writeBatch(ctx, "create", results, indexes, func(ctx context.Context, indexes []int) ([]domain.BatchResult, error) {
+		written, err := s.repository.CreateBatch(ctx, pickBatch(items, indexes))
+		if err != nil {
+			return nil, fmt.Errorf("error creating items in repository: %w", err)
+		}
+		return written, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.afterBatch(ctx, "create", results)
+}
+
+// UpdateBatch valida y actualiza varios items en una sola escritura a DB
+func (s *ItemsServiceImpl) UpdateBatch(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	if err := validateBatchSize(len(items)); err != nil {
+		return nil, err
+	}
+
+	results, indexes := s.validateBatch(items)
+
+	err := s.writeBatch(ctx, "update", results, indexes, func(ctx context.Context, indexes []int) ([]domain.BatchResult, error) {
+		written, err := s.repository.UpdateBatch(ctx, pickBatch(items, indexes))
+		if err != nil {
+			return nil, fmt.Errorf("error updating items in repository: %w", err)
+		}
+		return written, nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	// TODO: Borrar de cache
-	// TODO: Borrar de DB
+	return s.afterBatch(ctx, "update", results)
+}
+
+// DeleteBatch borra lógicamente varios items en una sola escritura a DB
+func (s *ItemsServiceImpl) DeleteBatch(ctx context.Context, refs []domain.ItemRef) ([]domain.BatchResult, error) {
+	if err := validateBatchSize(len(refs)); err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.BatchResult, len(refs))
+	for i, ref := range refs {
+		results[i] = domain.BatchResult{Index: i, ID: ref.ID}
+	}
+	rejectDuplicateIDs(results)
+
+	indexes := make([]int, 0, len(refs))
+	for i := range results {
+		if results[i].Err == nil {
+			indexes = append(indexes, i)
+		}
+	}
+
+	err := s.writeBatch(ctx, "delete", results, indexes, func(ctx context.Context, indexes []int) ([]domain.BatchResult, error) {
+		written, err := s.repository.DeleteBatch(ctx, pickBatch(refs, indexes))
+		if err != nil {
+			return nil, fmt.Errorf("error deleting items in repository: %w", err)
+		}
+		return written, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.afterBatch(ctx, "delete", results)
+}
+
+// Import valida y hace upsert de un lote de filas importadas (CSV/JSONL)
+// Las filas con ID actualizan ese item; las sin ID se matchean por nombre
+func (s *ItemsServiceImpl) Import(ctx context.Context, items []domain.Item) ([]domain.BatchResult, error) {
+	if err := validateBatchSize(len(items)); err != nil {
+		return nil, err
+	}
+
+	results, indexes := s.validateBatch(items)
+
+	err := s.writeBatch(ctx, "update", results, indexes, func(ctx context.Context, indexes []int) ([]domain.BatchResult, error) {
+		written, err := s.repository.UpsertBatch(ctx, pickBatch(items, indexes))
+		if err != nil {
+			return nil, fmt.Errorf("error importing items in repository: %w", err)
+		}
+		return written, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.afterBatch(ctx, "update", results)
+}
+
+// Export recorre todo el catálogo activo llamando a fn por cada item
+// Siempre va al repository: la cache no tiene el catálogo completo
+func (s *ItemsServiceImpl) Export(ctx context.Context, fn func(domain.Item) error) error {
+	if err := s.repository.Stream(ctx, fn); err != nil {
+		return fmt.Errorf("error exporting items from repository: %w", err)
+	}
+	return nil
+}
+
+// validateBatch aplica validateItem a cada item del batch y rechaza los IDs repetidos
+// Retorna los resultados (con error para los inválidos) y el índice de cada item válido
+func (s *ItemsServiceImpl) validateBatch(items []domain.Item) ([]domain.BatchResult, []int) {
+	results := make([]domain.BatchResult, len(items))
+	for i, item := range items {
+		results[i] = domain.BatchResult{Index: i, ID: item.ID}
+	}
+	rejectDuplicateIDs(results)
+
+	indexes := make([]int, 0, len(items))
+	for i, item := range items {
+		if results[i].Err != nil {
+			continue
+		}
+		if err := s.validateItem(item); err != nil {
+			results[i].Err = err
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	return results, indexes
+}
+
+// mergeBatch copia los resultados del repository (relativos a los items válidos)
+// a su posición en el batch original
+func mergeBatch(results []domain.BatchResult, indexes []int, written []domain.BatchResult) {
+	for i, res := range written {
+		res.Index = indexes[i]
+		results[indexes[i]] = res
+	}
+}
+
+// pickBatch retorna los elementos de values en las posiciones indexes
+func pickBatch[T any](values []T, indexes []int) []T {
+	picked := make([]T, len(indexes))
+	for i, index := range indexes {
+		picked[i] = values[index]
+	}
+	return picked
+}
+
+// writeBatch ejecuta write (con los índices del batch a escribir) y registra un
+// evento por item escrito, todo en la misma transacción: o se guardan los items
+// y sus eventos, o ninguno
+// Dentro de una transacción el error de un item (ej.: nombre duplicado) aborta
+// el resto (domain.ErrBatchAborted): se reintenta sin los items que fallaron
+func (s *ItemsServiceImpl) writeBatch(ctx context.Context, action string, results []domain.BatchResult, indexes []int,
+	write func(ctx context.Context, indexes []int) ([]domain.BatchResult, error)) error {
+	pending := indexes
+	for len(pending) > 0 {
+		var written []domain.BatchResult
+		err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			written, err = write(ctx, pending)
+			if err != nil {
+				return err
+			}
+			for _, res := range written {
+				if errors.Is(res.Err, domain.ErrBatchAborted) {
+					return domain.ErrBatchAborted
+				}
+			}
+
+			// 📨 Un mensaje por item afectado
+			for _, res := range written {
+				if res.Err != nil || res.Item == nil {
+					continue
+				}
+				data := batchEventData(action, res)
+				if err := s.publish(ctx, data); err != nil {
+					return fmt.Errorf("error publishing item %s: %w", data.Action(), err)
+				}
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, domain.ErrBatchAborted) {
+			return err
+		}
+		mergeBatch(results, pending, written)
+		if err == nil {
+			return nil
+		}
+
+		var retry []int
+		for _, i := range pending {
+			if errors.Is(results[i].Err, domain.ErrBatchAborted) {
+				retry = append(retry, i)
+			}
+		}
+		if len(retry) == len(pending) {
+			return fmt.Errorf("error writing batch in repository: %w", err)
+		}
+		pending = retry
+	}
+	return nil
+}
+
+// afterBatch actualiza la cache con los items ya confirmados por writeBatch:
+// los borrados se invalidan y el resto se cachea con un único CreateBatch
+func (s *ItemsServiceImpl) afterBatch(ctx context.Context, action string, results []domain.BatchResult) ([]domain.BatchResult, error) {
+	var cached []domain.Item
+	written := false
+
+	for _, res := range results {
+		if res.Err != nil || res.Item == nil {
+			continue
+		}
+		written = true
+
+		if action == "delete" {
+			s.logCacheError("delete", res.ID, s.cache.Delete(ctx, res.ID, 0))
+			continue
+		}
+		cached = append(cached, *res.Item)
+	}
+
+	if written {
+		s.invalidateLists(ctx)
+	}
+	if len(cached) == 0 {
+		return results, nil
+	}
+
+	// La escritura en DB ya se hizo: un error de cache no cambia el resultado
+	cacheResults, err := s.cache.CreateBatch(ctx, cached)
+	if err != nil {
+		s.logCacheError("create batch", "", err)
+		return results, nil
+	}
+	for _, res := range cacheResults {
+		s.logCacheError("create", res.ID, res.Err)
+	}
+
+	return results, nil
+}
+
+// batchEventData arma el payload del evento de un item escrito en batch
+// Los items creados (BatchResult.Created) siempre publican ItemCreated
+func batchEventData(action string, res domain.BatchResult) domain.ItemEventData {
+	if res.Created {
+		return domain.ItemCreated{Item: *res.Item}
+	}
+	switch action {
+	case "delete":
+		// Como Delete: el item antes del borrado (el worker suma 1 a la versión)
+		if res.Before != nil {
+			return domain.ItemDeleted{Item: *res.Before}
+		}
+		before := *res.Item
+		before.Version--
+		before.DeletedAt = nil
+		return domain.ItemDeleted{Item: before}
+	case "update":
+		return domain.ItemUpdated{Before: res.Before, After: *res.Item}
+	default:
+		return domain.ItemCreated{Item: *res.Item}
+	}
+}
+
+// publish registra el CloudEvent de un payload de items
+// Si ctx viene de WithTransaction, el evento se guarda en la misma transacción
+func (s *ItemsServiceImpl) publish(ctx context.Context, data domain.ItemEventData) error {
+	event, err := domain.NewItemEvent(uuid.New().String(), correlation.ID(ctx), data, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	return s.publisher.Publish(ctx, event)
+}
+
+// rejectDuplicateIDs marca con ErrInvalidQuery cada item que repite el ID de
+// uno anterior del batch: dos escrituras al mismo item en un BulkWrite no
+// ordenado no tienen un resultado definido
+func rejectDuplicateIDs(results []domain.BatchResult) {
+	seen := make(map[string]int, len(results))
+	for i, res := range results {
+		if res.ID == "" {
+			continue
+		}
+		// Los ObjectIDs en hex no distinguen mayúsculas
+		key := strings.ToLower(res.ID)
+		if first, ok := seen[key]; ok {
+			results[i].Err = fmt.Errorf("%w: duplicate id %s (already at index %d)", domain.ErrInvalidQuery, res.ID, first)
+			continue
+		}
+		seen[key] = i
+	}
+}
 
-	return errors.New("TODO: implementar Delete")
+// validateBatchSize limita el tamaño de los requests batch
+func validateBatchSize(size int) error {
+	if size == 0 {
+		return fmt.Errorf("%w: batch cannot be empty", domain.ErrInvalidQuery)
+	}
+	if size > domain.MaxBatchSize {
+		return fmt.Errorf("%w: batch cannot exceed %d items", domain.ErrInvalidQuery, domain.MaxBatchSize)
+	}
+	return nil
+}
+
+// loadTimeout acota una lectura compartida a DB (ver load)
+const loadTimeout = 5 * time.Second
+
+// load lee el item de DB y lo guarda en cache
+// 🚦 Los requests concurrentes por el mismo ID comparten una única lectura
+// (singleflight): una clave fría no genera una consulta a Mongo por request
+// La lectura no se cancela si se va el request que la inició (la esperan otros)
+func (s *ItemsServiceImpl) load(ctx context.Context, id string) (domain.Item, error) {
+	loaded := s.loads.DoChan(id, func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+
+		item, err := s.repository.GetByID(ctx, id)
+		if errors.Is(err, domain.ErrNotFound) {
+			// 🚫 Cache negativa: un tombstone corto evita que los requests por un
+			// ID inexistente vayan siempre a DB. Crear el item lo pisa
+			now := time.Now().UTC()
+			_, cacheErr := s.cache.Create(ctx, domain.Item{ID: id, DeletedAt: &now})
+			s.logCacheError("create", id, cacheErr)
+		}
+		if err != nil {
+			return domain.Item{}, fmt.Errorf("error getting item from repository: %w", err)
+		}
+
+		_, err = s.cache.Create(ctx, item)
+		s.logCacheError("create", id, err)
+
+		return item, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return domain.Item{}, ctx.Err()
+	case res := <-loaded:
+		if res.Err != nil {
+			return domain.Item{}, res.Err
+		}
+		return res.Val.(domain.Item), nil
+	}
+}
+
+// revalidate refresca en segundo plano un item vencido en cache
+// Si el item ya no existe load deja un tombstone en su lugar
+func (s *ItemsServiceImpl) revalidate(ctx context.Context, id string) {
+	_, err := s.load(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		log.Printf("⚠️ error revalidating item %s: %v", id, err)
+	}
+}
+
+// invalidateLists descarta los listados cacheados después de una escritura
+func (s *ItemsServiceImpl) invalidateLists(ctx context.Context) {
+	s.logCacheError("invalidate lists", "", s.cache.InvalidateLists(ctx))
+}
+
+// logCacheError registra un error de cache sin cortar la operación
+// La cache es una optimización: si falla se sigue con la DB. Los miss no se registran
+func (s *ItemsServiceImpl) logCacheError(op string, id string, err error) {
+	if err == nil || errors.Is(err, domain.ErrCacheMiss) {
+		return
+	}
+	log.Printf("⚠️ cache %s %s: %v", op, id, err)
 }
 
 // validateItem aplica reglas de negocio para validar un item
@@ -120,14 +768,65 @@ func (s *ItemsServiceImpl) Delete(ctx context.Context, id string) error {
 func (s *ItemsServiceImpl) validateItem(item domain.Item) error {
 	// 📝 Name es obligatorio y no puede estar vacío
 	if strings.TrimSpace(item.Name) == "" {
-		return errors.New("name is required and cannot be empty")
+		return fmt.Errorf("%w: name is required and cannot be empty", domain.ErrInvalidItem)
 	}
 
 	// 💰 Price debe ser >= 0 (productos gratis están permitidos)
 	if item.Price < 0 {
-		return errors.New("price must be greater than or equal to 0")
+		return fmt.Errorf("%w: price must be greater than or equal to 0", domain.ErrInvalidItem)
 	}
 
 	// ✅ Todas las validaciones pasaron
 	return nil
 }
+
+// sortableFields son los campos por los que se permite ordenar un listado
+var sortableFields = map[string]bool{
+	"name":       true,
+	"price":      true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// validateQuery valida los parámetros de un listado y completa los defaults
+func (s *ItemsServiceImpl) validateQuery(query domain.ItemsQuery) (domain.ItemsQuery, error) {
+	// 📄 Página y límite: defaults y tope máximo para no traer toda la colección
+	if query.Page < 0 || query.Limit < 0 {
+		return domain.ItemsQuery{}, fmt.Errorf("%w: page and limit must be positive", domain.ErrInvalidQuery)
+	}
+	if query.After != nil {
+		// 🔑 Keyset: el cursor reemplaza a la página y fija el orden (created_at, _id) asc
+		if query.Page != 0 {
+			return domain.ItemsQuery{}, fmt.Errorf("%w: page cannot be combined with after", domain.ErrInvalidQuery)
+		}
+		if (query.SortBy != "" && query.SortBy != "created_at") || query.SortDesc {
+			return domain.ItemsQuery{}, fmt.Errorf("%w: after only supports ascending created_at order", domain.ErrInvalidQuery)
+		}
+		query.SortBy = "created_at"
+	} else if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.SortBy == "" {
+		// Por defecto el orden del keyset: la primera página ya trae next_cursor
+		query.SortBy = "created_at"
+	}
+	if query.Limit == 0 {
+		query.Limit = domain.DefaultPageLimit
+	}
+	if query.Limit > domain.MaxPageLimit {
+		query.Limit = domain.MaxPageLimit
+	}
+
+	// ↕️ Solo campos conocidos (evita ordenar por campos arbitrarios de DB)
+	if query.SortBy != "" && !sortableFields[query.SortBy] {
+		return domain.ItemsQuery{}, fmt.Errorf("%w: cannot sort by %q", domain.ErrInvalidQuery, query.SortBy)
+	}
+
+	// 💰 Rango de precios coherente
+	if query.MinPrice != nil && query.MaxPrice != nil && *query.MinPrice > *query.MaxPrice {
+		return domain.ItemsQuery{}, fmt.Errorf("%w: min_price cannot be greater than max_price", domain.ErrInvalidQuery)
+	}
+
+	query.Name = strings.TrimSpace(query.Name)
+	return query, nil
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBatchEventData(t *testing.T) {
+	deletedAt := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	before := domain.Item{ID: "a", Name: "Café", Price: 10, Version: 3}
+	updated := domain.Item{ID: "a", Name: "Café", Price: 12, Version: 4}
+	deleted := domain.Item{ID: "a", Name: "Café", Price: 10, Version: 4, DeletedAt: &deletedAt}
+	created := domain.Item{ID: "b", Name: "Té", Price: 5, Version: 1}
+
+	tests := []struct {
+		name   string
+		action string
+		res    domain.BatchResult
+		want   domain.ItemEventData
+	}{
+		{
+			name:   "create",
+			action: "create",
+			res:    domain.BatchResult{Item: &created, Created: true},
+			want:   domain.ItemCreated{Item: created},
+		},
+		{
+			name:   "upsert que crea",
+			action: "update",
+			res:    domain.BatchResult{Item: &created, Created: true},
+			want:   domain.ItemCreated{Item: created},
+		},
+		{
+			name:   "update con estado previo",
+			action: "update",
+			res:    domain.BatchResult{Item: &updated, Before: &before},
+			want:   domain.ItemUpdated{Before: &before, After: updated},
+		},
+		{
+			name:   "update sin estado previo",
+			action: "update",
+			res:    domain.BatchResult{Item: &updated},
+			want:   domain.ItemUpdated{After: updated},
+		},
+		{
+			// Como Delete: el item antes del borrado
+			name:   "delete con estado previo",
+			action: "delete",
+			res:    domain.BatchResult{Item: &deleted, Before: &before},
+			want:   domain.ItemDeleted{Item: before},
+		},
+		{
+			name:   "delete sin estado previo",
+			action: "delete",
+			res:    domain.BatchResult{Item: &deleted},
+			want:   domain.ItemDeleted{Item: domain.Item{ID: "a", Name: "Café", Price: 10, Version: 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := batchEventData(tt.action, tt.res)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("batchEventData(%q) = %+v, want %+v", tt.action, got, tt.want)
+			}
+		})
+	}
+
+	// El fallback no modifica el item del resultado
+	if deleted.Version != 4 || deleted.DeletedAt == nil {
+		t.Errorf("batchEventData modified the result item: %+v", deleted)
+	}
+}
+
+func TestValidateQuerySort(t *testing.T) {
+	after := &domain.ItemsCursor{ID: "a"}
+
+	tests := []struct {
+		name     string
+		query    domain.ItemsQuery
+		wantSort string
+		wantErr  bool
+	}{
+		{name: "sin sort: orden del keyset", query: domain.ItemsQuery{}, wantSort: "created_at"},
+		{name: "sort explícito", query: domain.ItemsQuery{SortBy: "price"}, wantSort: "price"},
+		{name: "cursor sin sort", query: domain.ItemsQuery{After: after}, wantSort: "created_at"},
+		{name: "cursor con otro sort", query: domain.ItemsQuery{After: after, SortBy: "price"}, wantErr: true},
+	}
+
+	s := &ItemsServiceImpl{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.validateQuery(tt.query)
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrInvalidQuery) {
+					t.Fatalf("validateQuery() error = %v, want ErrInvalidQuery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateQuery() error = %v", err)
+			}
+			if got.SortBy != tt.wantSort || got.SortDesc {
+				t.Errorf("validateQuery() sort = %q desc=%v, want %q asc", got.SortBy, got.SortDesc, tt.wantSort)
+			}
+		})
+	}
+}
+
+func TestRejectDuplicateIDs(t *testing.T) {
+	results := []domain.BatchResult{
+		{Index: 0, ID: "65a1b2c3d4e5f60718293a4b"},
+		{Index: 1, ID: ""},
+		{Index: 2, ID: "65A1B2C3D4E5F60718293A4B"},
+		{Index: 3, ID: ""},
+		{Index: 4, ID: "65a1b2c3d4e5f60718293a4c"},
+		{Index: 5, ID: "65a1b2c3d4e5f60718293a4b"},
+	}
+
+	rejectDuplicateIDs(results)
+
+	// Solo las repeticiones: la primera aparición y los items sin ID siguen válidos
+	wantDuplicate := []bool{false, false, true, false, false, true}
+	for i, res := range results {
+		if duplicate := errors.Is(res.Err, domain.ErrInvalidQuery); duplicate != wantDuplicate[i] || (!duplicate && res.Err != nil) {
+			t.Errorf("results[%d].Err = %v, want duplicate=%v", i, res.Err, wantDuplicate[i])
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ItemsCache es la cache que mantiene al día el CacheInvalidator
+type ItemsCache interface {
+	Create(ctx context.Context, item domain.Item) (domain.Item, error)
+	GetByID(ctx context.Context, id string) (domain.Item, error)
+	InvalidateLists(ctx context.Context) error
+}
+
+// CacheInvalidator aplica los eventos de items a la cache local de una réplica
+// Los eventos traen el item completo, así que en lugar de borrar la entrada la
+// refrescamos; los borrados dejan un tombstone. Cualquier evento descarta
+// además los listados cacheados de la réplica
+type CacheInvalidator struct {
+	cache ItemsCache
+}
+
+// NewCacheInvalidator crea el invalidador sobre la cache indicada
+func NewCacheInvalidator(cache ItemsCache) *CacheInvalidator {
+	return &CacheInvalidator{cache: cache}
+}
+
+// Register registra los handlers del invalidador en el dispatcher
+func (c *CacheInvalidator) Register(d *Dispatcher) {
+	d.Handle(domain.ItemEventType("create"), c.handleCreated)
+	d.Handle(domain.ItemEventType("update"), c.handleUpdated)
+	d.Handle(domain.ItemEventType("restore"), c.handleRestored)
+	d.Handle(domain.ItemEventType("delete"), c.handleDeleted)
+	d.Handle(domain.ItemEventType("purge"), c.handlePurged)
+}
+
+func (c *CacheInvalidator) handleCreated(ctx context.Context, event domain.CloudEvent) error {
+	var data domain.ItemCreated
+	if err := event.DecodeData(&data); err != nil {
+		return err
+	}
+	return c.refresh(ctx, data.Item)
+}
+
+func (c *CacheInvalidator) handleUpdated(ctx context.Context, event domain.CloudEvent) error {
+	var data domain.ItemUpdated
+	if err := event.DecodeData(&data); err != nil {
+		return err
+	}
+	return c.refresh(ctx, data.After)
+}
+
+func (c *CacheInvalidator) handleRestored(ctx context.Context, event domain.CloudEvent) error {
+	var data domain.ItemRestored
+	if err := event.DecodeData(&data); err != nil {
+		return err
+	}
+	return c.refresh(ctx, data.Item)
+}
+
+func (c *CacheInvalidator) handleDeleted(ctx context.Context, event domain.CloudEvent) error {
+	var data domain.ItemDeleted
+	if err := event.DecodeData(&data); err != nil {
+		return err
+	}
+	// El borrado lógico incrementa la versión del último estado conocido
+	return c.tombstone(ctx, data.Item.ID, data.Item.Version+1)
+}
+
+func (c *CacheInvalidator) handlePurged(ctx context.Context, event domain.CloudEvent) error {
+	var data domain.ItemPurged
+	if err := event.DecodeData(&data); err != nil {
+		return err
+	}
+	return c.tombstone(ctx, data.ID, 0)
+}
+
+// refresh guarda el item salvo que la cache ya tenga una versión más nueva
+// (los eventos pueden llegar desordenados)
+func (c *CacheInvalidator) refresh(ctx context.Context, item domain.Item) error {
+	if err := c.invalidateLists(ctx); err != nil {
+		return err
+	}
+	if cached, err := c.cache.GetByID(ctx, item.ID); cacheHit(err) && cached.Version > item.Version {
+		return nil
+	}
+	if _, err := c.cache.Create(ctx, item); err != nil {
+		return fmt.Errorf("error refreshing item %s in cache: %w", item.ID, err)
+	}
+	return nil
+}
+
+// tombstone marca el item como borrado en la cache
+// version 0 (purge) pisa cualquier versión cacheada
+func (c *CacheInvalidator) tombstone(ctx context.Context, id string, version int64) error {
+	if err := c.invalidateLists(ctx); err != nil {
+		return err
+	}
+	if cached, err := c.cache.GetByID(ctx, id); cacheHit(err) && version > 0 && cached.Version > version {
+		return nil
+	}
+	now := time.Now().UTC()
+	if _, err := c.cache.Create(ctx, domain.Item{ID: id, Version: version, DeletedAt: &now}); err != nil {
+		return fmt.Errorf("error creating tombstone for item %s in cache: %w", id, err)
+	}
+	return nil
+}
+
+// invalidateLists descarta los listados cacheados: cualquier escritura puede
+// cambiar cualquier página, con o sin versión más nueva en la cache
+func (c *CacheInvalidator) invalidateLists(ctx context.Context) error {
+	if err := c.cache.InvalidateLists(ctx); err != nil {
+		return fmt.Errorf("error invalidating cached lists: %w", err)
+	}
+	return nil
+}
+
+// cacheHit indica si GetByID encontró el item (aunque esté vencido)
+func cacheHit(err error) bool {
+	return err == nil || errors.Is(err, domain.ErrCacheStale)
+}
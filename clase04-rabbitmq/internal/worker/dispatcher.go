@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"context"
+	"fmt"
+	"log"
+)
+
+// Handler procesa un evento de items de un type determinado
+type Handler func(ctx context.Context, event domain.CloudEvent) error
+
+// Dispatcher reparte cada evento a los handlers registrados para su type
+type Dispatcher struct {
+	handlers map[string][]Handler
+}
+
+// NewDispatcher crea un dispatcher sin handlers
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{handlers: make(map[string][]Handler)}
+}
+
+// Handle registra un handler para un type CloudEvents (ej.: domain.ItemEventType("create"))
+// Un type puede tener varios handlers: se ejecutan en el orden de registro
+func (d *Dispatcher) Handle(eventType string, handler Handler) {
+	d.handlers[eventType] = append(d.handlers[eventType], handler)
+}
+
+// Dispatch ejecuta los handlers del type del evento
+// Un evento sin handlers se ignora (no es un error: el productor puede
+// agregar eventos o versiones nuevas antes que el worker)
+func (d *Dispatcher) Dispatch(ctx context.Context, event domain.CloudEvent) error {
+	handlers, ok := d.handlers[event.Type]
+	if !ok {
+		log.Printf("worker: no handler for event type %q (event %s), skipping", event.Type, event.ID)
+		return nil
+	}
+
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			return fmt.Errorf("error handling %s event for item %s: %w", event.Type, event.Subject, err)
+		}
+	}
+	return nil
+}
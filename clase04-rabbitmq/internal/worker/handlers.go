@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"context"
+	"log"
+)
+
+// LogHandler registra el evento recibido en el log
+// 🎯 Punto de partida para handlers reales (invalidar cache, indexar, notificar...)
+func LogHandler(_ context.Context, event domain.CloudEvent) error {
+	log.Printf("📨 %s item %s (event %s, correlation %s)", event.Type, event.Subject, event.ID, event.CorrelationID)
+	return nil
+}
+
+// UpdateLogHandler muestra cómo leer el payload tipado: qué cambió en un update
+func UpdateLogHandler(_ context.Context, event domain.CloudEvent) error {
+	var data domain.ItemUpdated
+	if err := event.DecodeData(&data); err != nil {
+		return err
+	}
+	if data.Before == nil {
+		log.Printf("✏️ item %s updated: name=%q price=%.2f", data.After.ID, data.After.Name, data.After.Price)
+		return nil
+	}
+	log.Printf("✏️ item %s updated: name %q -> %q, price %.2f -> %.2f",
+		data.After.ID, data.Before.Name, data.After.Name, data.Before.Price, data.After.Price)
+	return nil
+}
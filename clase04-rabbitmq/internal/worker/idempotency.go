@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"clase04-rabbitmq/internal/domain"
+	"context"
+	"log"
+	"sync/atomic"
+)
+
+// DedupeStore registra los eventos ya procesados (ver repository.MongoProcessedEventsRepository)
+type DedupeStore interface {
+	// Claim reclama el evento de forma atómica: false si ya fue procesado,
+	// error si otro worker lo tiene reclamado
+	Claim(ctx context.Context, eventID string) (bool, error)
+	// Release libera el reclamo de un evento que falló
+	Release(ctx context.Context, eventID string) error
+	MarkProcessed(ctx context.Context, eventID string) error
+}
+
+// DedupeStats son los contadores de un Deduplicator
+type DedupeStats struct {
+	Processed  int64 `json:"processed"`
+	Duplicates int64 `json:"duplicates_dropped"`
+}
+
+// Deduplicator hace idempotente a un handler: los eventos con un ID ya
+// procesado (redeliveries, reintentos del outbox) se confirman sin procesarlos
+// El evento se reclama antes de procesarlo (así dos workers concurrentes no lo
+// procesan a la vez) y se marca como procesado después. Si el handler falla se
+// libera el reclamo; si el proceso se cae en el medio el reclamo vence y se
+// vuelve a procesar (at-least-once), nunca se pierde
+type Deduplicator struct {
+	store DedupeStore
+	next  Handler
+
+	processed  atomic.Int64
+	duplicates atomic.Int64
+}
+
+// NewDeduplicator envuelve next con el registro de eventos procesados
+func NewDeduplicator(store DedupeStore, next Handler) *Deduplicator {
+	return &Deduplicator{store: store, next: next}
+}
+
+// Handle procesa el evento salvo que ya se haya procesado antes
+func (d *Deduplicator) Handle(ctx context.Context, event domain.CloudEvent) error {
+	claimed, err := d.store.Claim(ctx, event.ID)
+	if err != nil {
+		// Sin registro (o con el evento en proceso en otro worker) no sabemos
+		// si se va a procesar bien: que se reintente
+		return err
+	}
+	if !claimed {
+		d.duplicates.Add(1)
+		log.Printf("worker: duplicate event %s (%s), dropping", event.ID, event.Type)
+		return nil
+	}
+
+	if err := d.next(ctx, event); err != nil {
+		// El reintento tiene que poder reclamarlo de nuevo (si falla, vence solo)
+		if releaseErr := d.store.Release(ctx, event.ID); releaseErr != nil {
+			log.Printf("worker: error releasing event %s: %v", event.ID, releaseErr)
+		}
+		return err
+	}
+	d.processed.Add(1)
+
+	// ⚠️ Si falla el registro el evento ya se procesó: lo confirmamos igual
+	// (un duplicado posterior se procesaría otra vez)
+	if err := d.store.MarkProcessed(ctx, event.ID); err != nil {
+		log.Printf("worker: error recording event %s as processed: %v", event.ID, err)
+	}
+	return nil
+}
+
+// Stats retorna los contadores actuales
+func (d *Deduplicator) Stats() DedupeStats {
+	return DedupeStats{
+		Processed:  d.processed.Load(),
+		Duplicates: d.duplicates.Load(),
+	}
+}
@@ -1,11 +1,14 @@
 // Seed some data if the collection is empty
+// Los campos siguen el formato de dao.Item (snake_case); los índices y el
+// schema validator los crea `go run ./cmd/migrate`
 db = db.getSiblingDB(process.env.MONGO_INITDB_DATABASE || "demo");
 const col = db.getCollection("items");
 if (col.countDocuments() === 0) {
+  const now = new Date();
   col.insertMany([
-    { name: "Notebook", price: 12.5, createdAt: new Date() },
-    { name: "Coffee", price: 3.25, createdAt: new Date() },
-    { name: "Keyboard", price: 22.0, createdAt: new Date() }
+    { name: "Notebook", price: 12.5, version: NumberLong(1), created_at: now, updated_at: now },
+    { name: "Coffee", price: 3.25, version: NumberLong(1), created_at: now, updated_at: now },
+    { name: "Keyboard", price: 22.0, version: NumberLong(1), created_at: now, updated_at: now }
   ]);
   print("Seeded initial items");
 } else {